@@ -0,0 +1,240 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configFile 配置文件（TOML）的内容
+//
+//	[defaults]            # 默认选项，键名与长参数名相同
+//	unescape = true
+//	line-color = "green"
+//
+//	[themes.solarized]    # 命名配色方案，通过 theme = "solarized" 或 --theme 使用
+//	base = "light"
+//	gutter = "#268bd2"
+//	key = "blue"
+//
+//	[keys]                # 按键绑定：按键名称 = 操作
+//	"ctrl+n" = "next-match"
+//
+//	[[profile]]           # 按文件名匹配的配置，覆盖 [defaults]
+//	pattern = "*.json.log"
+//	json-color = true
+type configFile struct {
+	Defaults map[string]interface{}       `toml:"defaults"`
+	Themes   map[string]map[string]string `toml:"themes"`
+	Keys     map[string]string            `toml:"keys"`
+	Profiles []map[string]interface{}     `toml:"profile"`
+}
+
+// configThemes 配置文件中定义的命名配色方案
+var configThemes = map[string]map[string]string{}
+
+// configPath 返回默认配置文件的位置：$XDG_CONFIG_HOME/loglens/config.toml，
+// 未设置时为 ~/.config/loglens/config.toml
+func configPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "loglens", "config.toml")
+}
+
+// loadConfig 读取配置文件并应用到命令行参数、配色方案和按键绑定
+// path 为空时使用默认位置，默认位置的文件不存在时不做任何事；
+// logFile 用于匹配 [[profile]]。命令行中显式指定的参数优先于配置文件
+func loadConfig(path, logFile string) error {
+	explicit := path != ""
+	if !explicit {
+		path = configPath()
+		if path == "" {
+			return nil
+		}
+	}
+
+	var cfg configFile
+	if _, err := toml.DecodeFile(path, &cfg); err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil
+		}
+		return fmt.Errorf("无法读取配置文件 %s: %v", path, err)
+	}
+
+	for name, theme := range cfg.Themes {
+		configThemes[name] = theme
+	}
+
+	// 合并默认选项和匹配的 profile（按出现顺序，后面的覆盖前面的）
+	options := map[string]interface{}{}
+	for name, value := range cfg.Defaults {
+		options[name] = value
+	}
+	for i, profile := range cfg.Profiles {
+		pattern, _ := profile["pattern"].(string)
+		if pattern == "" {
+			return fmt.Errorf("配置文件 %s: 第 %d 个 profile 缺少 pattern", path, i+1)
+		}
+		matched, err := matchProfile(pattern, logFile)
+		if err != nil {
+			return fmt.Errorf("配置文件 %s: profile 的 pattern 不合法 %q: %v", path, pattern, err)
+		}
+		if !matched {
+			continue
+		}
+		for name, value := range profile {
+			if name != "pattern" {
+				options[name] = value
+			}
+		}
+	}
+	if err := applyOptions(options); err != nil {
+		return fmt.Errorf("配置文件 %s: %v", path, err)
+	}
+
+	if err := applyKeyBindings(cfg.Keys); err != nil {
+		return fmt.Errorf("配置文件 %s: %v", path, err)
+	}
+	return nil
+}
+
+// matchProfile 检查日志文件是否匹配 profile 的模式
+// 模式中不含路径分隔符时只匹配文件名，否则匹配完整路径
+func matchProfile(pattern, logFile string) (bool, error) {
+	if logFile == "" {
+		// 从标准输入读取时没有文件名，只检查模式本身是否合法
+		_, err := filepath.Match(pattern, "")
+		return false, err
+	}
+	if !strings.Contains(pattern, "/") {
+		return filepath.Match(pattern, filepath.Base(logFile))
+	}
+	return filepath.Match(pattern, logFile)
+}
+
+// explicitFlags 返回命令行中显式指定的参数
+// 短参数和长参数（如 -u 和 --unescape）绑定同一个变量，任意一个被指定都算作指定
+func explicitFlags() map[flag.Value]bool {
+	set := map[flag.Value]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Value] = true
+	})
+	return set
+}
+
+// applyOptions 将配置中的选项设置到对应的命令行参数上，跳过命令行中已显式指定的参数
+func applyOptions(options map[string]interface{}) error {
+	explicit := explicitFlags()
+
+	// 按名称排序，保证出错时的提示稳定
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := flag.Lookup(name)
+		if f == nil || name == "help" || name == "config" {
+			return fmt.Errorf("未知的选项: %s", name)
+		}
+		if explicit[f.Value] {
+			continue
+		}
+		if list, ok := f.Value.(*stringList); ok {
+			// 可重复的参数（如 highlight）接受数组，每一项单独设置
+			if err := setListOption(list, options[name]); err != nil {
+				return fmt.Errorf("选项 %s: %v", name, err)
+			}
+			continue
+		}
+		value, err := optionString(options[name])
+		if err != nil {
+			return fmt.Errorf("选项 %s: %v", name, err)
+		}
+		if err := f.Value.Set(value); err != nil {
+			return fmt.Errorf("选项 %s 的值不合法: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// setListOption 将单个值或数组设置到可重复的参数上
+func setListOption(list *stringList, value interface{}) error {
+	items, ok := value.([]interface{})
+	if !ok {
+		items = []interface{}{value}
+	}
+	for _, item := range items {
+		s, err := optionString(item)
+		if err != nil {
+			return err
+		}
+		list.Set(s)
+	}
+	return nil
+}
+
+// optionString 将 TOML 值转换为命令行参数的字符串形式
+func optionString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case []interface{}:
+		// 数组按逗号连接，例如 escapes = ["basic", "octal"]
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, err := optionString(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", fmt.Errorf("不支持的值类型 %T", value)
+}
+
+// applyKeyBindings 在默认按键绑定的基础上应用配置中的绑定
+// 操作名为 "none" 时取消该按键的绑定
+func applyKeyBindings(keys map[string]string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	bindings := make(map[string]pagerAction, len(defaultKeyBindings))
+	for key, action := range defaultKeyBindings {
+		bindings[key] = action
+	}
+	for key, name := range keys {
+		if !validKeyName(key) {
+			return fmt.Errorf("未知的按键名称: %s（应为单个字符、ctrl+<字母>，或 space、enter、esc、pgdn 等按键名）", key)
+		}
+		if name == "none" {
+			delete(bindings, key)
+			continue
+		}
+		action := pagerAction(name)
+		if !validActions[action] {
+			return fmt.Errorf("按键 %s 绑定了未知的操作: %s", key, name)
+		}
+		bindings[key] = action
+	}
+	keyBindings = bindings
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+)
+
+// 去重模式（--uniq）
+const (
+	uniqOff   = ""      // 不去重
+	uniqMask  = "mask"  // 屏蔽数字、UUID、时间戳后相同的连续行合并为一行（--uniq）
+	uniqExact = "exact" // 只合并完全相同的连续行（--uniq=exact）
+)
+
+// uniqFlag --uniq 参数，单独使用时等同于 --uniq=mask
+type uniqFlag string
+
+// String 返回当前的去重模式（flag.Value 接口）
+func (u *uniqFlag) String() string {
+	return string(*u)
+}
+
+// Set 解析 --uniq 的值，true/false 对应不带值使用和关闭（flag.Value 接口）
+func (u *uniqFlag) Set(value string) error {
+	switch value {
+	case "true", uniqMask:
+		*u = uniqMask
+	case "false", "":
+		*u = uniqOff
+	case uniqExact:
+		*u = uniqExact
+	default:
+		return fmt.Errorf("--uniq 的值必须是 mask 或 exact: %s", value)
+	}
+	return nil
+}
+
+// IsBoolFlag 允许不带值使用 --uniq
+func (u *uniqFlag) IsBoolFlag() bool {
+	return true
+}
+
+// maskPatterns 比较时屏蔽的可变部分，按顺序替换（时间戳先于数字）
+var maskPatterns = []struct {
+	re          *regexp.Regexp
+	placeholder string
+}{
+	{regexp.MustCompile(`\d{4}[-/.]\d{2}[-/.]\d{2}([T ]\d{2}:\d{2}(:\d{2})?([.,]\d+)?(Z|[+-]\d{2}:?\d{2})?)?|\d{2}:\d{2}:\d{2}([.,]\d+)?`), "<time>"},
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`0[xX][0-9a-fA-F]+|\d+(\.\d+)?`), "<num>"},
+}
+
+// timestampPattern 从行中提取时间戳，用于显示重复行的起止时间
+var timestampPattern = maskPatterns[0].re
+
+// maskVariables 将行中的时间戳、UUID 和数字替换为占位符
+func maskVariables(line string) string {
+	for _, p := range maskPatterns {
+		line = p.re.ReplaceAllString(line, p.placeholder)
+	}
+	return line
+}
+
+// dedupeKey 返回比较重复行时使用的内容
+func dedupeKey(line, mode string) string {
+	if mode == uniqMask {
+		return maskVariables(line)
+	}
+	return line
+}
+
+// dupSummary 生成重复行的摘要："×N" 以及第一行和最后一行中的时间戳
+func dupSummary(count int, first, last string) string {
+	summary := fmt.Sprintf(" ×%d", count)
+	from, to := timestampPattern.FindString(first), timestampPattern.FindString(last)
+	if from != "" && to != "" && from != to {
+		summary += fmt.Sprintf(" (%s – %s)", from, to)
+	}
+	return summary
+}
+
+// dupGroup 一组连续的重复行 [first, last]
+type dupGroup struct {
+	first int
+	last  int
+}
+
+// findDuplicates 读取文件，找出所有连续重复的行（按显示内容比较）
+func findDuplicates(filePath string, totalLines int, mode string) ([]dupGroup, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := newLogScanner(file)
+
+	var groups []dupGroup
+	prevKey := ""
+	groupStart := 0
+	n := 0
+	for ; n < totalLines && scanner.Scan(); n++ {
+		key := dedupeKey(displayText(scanner.Text()), mode)
+		if n > 0 && key == prevKey {
+			continue
+		}
+		if n-groupStart > 1 {
+			groups = append(groups, dupGroup{first: groupStart, last: n - 1})
+		}
+		prevKey = key
+		groupStart = n
+	}
+	if n-groupStart > 1 {
+		groups = append(groups, dupGroup{first: groupStart, last: n - 1})
+	}
+	return groups, scanner.Err()
+}
+
+// findGroup 返回包含第 line 行的重复行组
+func findGroup(groups []dupGroup, line int) (dupGroup, bool) {
+	k := sort.Search(len(groups), func(i int) bool { return groups[i].last >= line })
+	if k < len(groups) && groups[k].first <= line {
+		return groups[k], true
+	}
+	return dupGroup{}, false
+}
@@ -5,6 +5,8 @@ go 1.24.0
 toolchain go1.24.10
 
 require (
-	golang.org/x/sys v0.38.0 // indirect
-	golang.org/x/term v0.37.0 // indirect
+	github.com/BurntSushi/toml v1.5.0
+	golang.org/x/term v0.37.0
 )
+
+require golang.org/x/sys v0.38.0 // indirect
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// highlight 一个固定高亮的关键词
+type highlight struct {
+	pattern string
+	query   searchQuery // 与搜索相同，支持 "字段:文本" 的形式
+	code    string      // ANSI 样式代码
+}
+
+// highlights 当前的固定高亮列表（由 --highlight 和分页器中的 :hl 命令添加）
+// 在分页视图、JSON 格式化视图和非交互模式的输出中都会生效
+var highlights []highlight
+
+// highlightSpecs 命令行中的 --highlight 参数，可以重复指定
+var highlightSpecs stringList
+
+// stringList 可重复指定的字符串参数
+type stringList []string
+
+// String 返回以逗号分隔的所有值（flag.Value 接口）
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+// Set 追加一个值，每次指定参数时调用（flag.Value 接口）
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// addHighlight 按描述添加固定高亮
+// 描述为 "<模式>"（自动选择颜色）或 "@<颜色> <模式>"，颜色作为背景色，例如 "@red trace-123"、"@#87d787 retry"
+// 已存在的模式只更新颜色
+func addHighlight(spec string) error {
+	pattern := spec
+	code := ""
+	if rest, ok := strings.CutPrefix(spec, "@"); ok {
+		color, p, found := strings.Cut(rest, " ")
+		if !found || strings.TrimSpace(p) == "" {
+			return fmt.Errorf("高亮缺少模式: %s", spec)
+		}
+		c, err := parseStyle("black on " + color)
+		if err != nil {
+			return err
+		}
+		pattern = strings.TrimSpace(p)
+		if colorOutput {
+			// 禁用颜色时忽略指定的颜色，使用配色方案中的样式
+			code = c
+		}
+	}
+	if pattern == "" {
+		return fmt.Errorf("高亮缺少模式")
+	}
+
+	for i := range highlights {
+		if highlights[i].pattern == pattern {
+			if code != "" {
+				highlights[i].code = code
+			}
+			return nil
+		}
+	}
+	if code == "" {
+		code = nextHighlightColor()
+	}
+	highlights = append(highlights, highlight{pattern: pattern, query: parseQuery(pattern), code: code})
+	return nil
+}
+
+// nextHighlightColor 从配色方案的高亮色板中选择第一个未被使用的颜色，全部用过时循环使用
+func nextHighlightColor() string {
+	palette := activeTheme.Highlights
+	if len(palette) == 0 {
+		return activeTheme.Search
+	}
+	used := map[string]bool{}
+	for _, h := range highlights {
+		used[h.code] = true
+	}
+	for _, code := range palette {
+		if !used[code] {
+			return code
+		}
+	}
+	return palette[len(highlights)%len(palette)]
+}
+
+// removeHighlight 删除第 i 个高亮
+func removeHighlight(i int) {
+	if i >= 0 && i < len(highlights) {
+		highlights = append(highlights[:i], highlights[i+1:]...)
+	}
+}
+
+// highlightSpans 返回行中所有固定高亮关键词的着色区间，排在后面的高亮优先
+func highlightSpans(line string) []styleSpan {
+	var spans []styleSpan
+	for _, h := range highlights {
+		for _, r := range h.query.find(line) {
+			spans = append(spans, styleSpan{start: r[0], end: r[1], code: h.code})
+		}
+	}
+	return spans
+}
+
+// showHighlightMenu 显示固定高亮列表，可以选择并删除其中的项（:hl 命令）
+func showHighlightMenu() {
+	selected := 0
+	for {
+		fmt.Print("\033[2J\033[H")
+		fmt.Print(paint(activeTheme.Title, "=== 固定高亮 ===") + "\r\n\r\n")
+		if len(highlights) == 0 {
+			fmt.Print(paint(activeTheme.Muted, "(没有高亮，使用 :hl <模式> 添加)") + "\r\n")
+		}
+		for i, h := range highlights {
+			marker := "  "
+			if i == selected {
+				marker = "> "
+			}
+			fmt.Printf("%s%2d. %s\r\n", marker, i+1, paint(h.code, h.pattern))
+		}
+		fmt.Print("\r\n" + paint(activeTheme.Muted, "j/k 选择  d 删除  D 全部删除  q/Esc 返回"))
+
+		key, err := keyInput.nextKey()
+		if err != nil {
+			return
+		}
+		switch key.name() {
+		case "j", "down":
+			if selected < len(highlights)-1 {
+				selected++
+			}
+		case "k", "up":
+			if selected > 0 {
+				selected--
+			}
+		case "d", "x", "delete":
+			removeHighlight(selected)
+			if selected >= len(highlights) && selected > 0 {
+				selected--
+			}
+		case "D":
+			highlights = nil
+			selected = 0
+		case "q", "esc", "enter":
+			return
+		}
+	}
+}
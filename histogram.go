@@ -0,0 +1,510 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// histogramLevels 柱状图中自下而上堆叠的等级，"" 表示没有等级的记录
+var histogramLevels = []string{"error", "warn", "info", "debug", ""}
+
+// histogramShades 禁用颜色时各等级使用的字符，与 histogramLevels 对应
+var histogramShades = []string{"█", "▓", "▒", "░", "·"}
+
+// histogramSteps 可选的时间桶大小：默认每分钟一个桶，桶太多放不下时依次加大
+var histogramSteps = []time.Duration{
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour, 7 * 24 * time.Hour,
+}
+
+// 柱状图的布局
+const (
+	histogramGutter = 8  // 左侧刻度占的列数，与分页器的行号前缀相同
+	histogramRows   = 12 // lg histogram 输出的图表高度
+	histogramMaxBar = 4  // 每个桶最多占的列数
+)
+
+// timePoint 一条有时间戳的记录
+type timePoint struct {
+	t     time.Time
+	level int // 在 histogramLevels 中的下标
+	line  int // 记录首行的行号（0 基）
+}
+
+// newTimePoint 解析记录首行的时间戳和等级，没有时间戳时返回 false
+func newTimePoint(line string, lineNum int) (timePoint, bool) {
+	t, ok := lineTime(line)
+	if !ok {
+		return timePoint{}, false
+	}
+	level := lineLevel(line)
+	for i, l := range histogramLevels {
+		if l == level {
+			return timePoint{t: t, level: i, line: lineNum}, true
+		}
+	}
+	return timePoint{t: t, level: len(histogramLevels) - 1, line: lineNum}, true
+}
+
+// dropUndated 有带日期的记录时丢弃只有时刻的记录（年份为 0），
+// 否则两者混在一起会把时间范围拉长到两千多年
+func dropUndated(points []timePoint) []timePoint {
+	dated := 0
+	for _, p := range points {
+		if p.t.Year() != 0 {
+			dated++
+		}
+	}
+	if dated == 0 || dated == len(points) {
+		return points
+	}
+	kept := make([]timePoint, 0, dated)
+	for _, p := range points {
+		if p.t.Year() != 0 {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// timeBucket 一个时间桶内的记录数
+type timeBucket struct {
+	start  time.Time
+	counts []int // 各等级的记录数，与 histogramLevels 对应
+	total  int
+	first  int // 桶中第一条记录的行号，没有记录时为 -1
+}
+
+// histogram 按时间分桶的记录数，桶是连续的（包括没有记录的桶）
+type histogram struct {
+	step    time.Duration
+	buckets []timeBucket
+	records int // 有时间戳的记录数
+	max     int // 最大的桶的记录数
+}
+
+// newHistogram 将记录分桶，选择使桶数不超过 maxBuckets 的最小桶大小
+func newHistogram(points []timePoint, maxBuckets int) *histogram {
+	h := &histogram{step: histogramSteps[0], records: len(points)}
+	if maxBuckets < 1 {
+		maxBuckets = 1
+	}
+	if len(points) == 0 {
+		return h
+	}
+	first, last := points[0].t, points[0].t
+	for _, p := range points {
+		if p.t.Before(first) {
+			first = p.t
+		}
+		if p.t.After(last) {
+			last = p.t
+		}
+	}
+	count := func(step time.Duration) int {
+		return int(last.Truncate(step).Sub(first.Truncate(step))/step) + 1
+	}
+	for _, step := range histogramSteps {
+		h.step = step
+		if count(step) <= maxBuckets {
+			break
+		}
+	}
+	// 时间范围太长、最大的桶也放不下时，按最大桶大小的整数倍继续加大，
+	// 使每条记录都落在自己的桶中；桶大小超过时间范围后最多两个桶，不再加大
+	largest := histogramSteps[len(histogramSteps)-1]
+	span := last.Sub(first)
+	for k := int(span/largest)/maxBuckets + 1; count(h.step) > maxBuckets && h.step <= span; k++ {
+		h.step = time.Duration(k) * largest
+	}
+
+	start := first.Truncate(h.step)
+	n := count(h.step)
+	h.buckets = make([]timeBucket, n)
+	for i := range h.buckets {
+		h.buckets[i] = timeBucket{start: start.Add(time.Duration(i) * h.step), counts: make([]int, len(histogramLevels)), first: -1}
+	}
+	for _, p := range points {
+		b := &h.buckets[h.index(p.t)]
+		b.counts[p.level]++
+		b.total++
+		if b.first < 0 || p.line < b.first {
+			b.first = p.line
+		}
+		if b.total > h.max {
+			h.max = b.total
+		}
+	}
+	return h
+}
+
+// index 返回时间 t 所在的桶
+func (h *histogram) index(t time.Time) int {
+	return clampIndex(int(t.Truncate(h.step).Sub(h.buckets[0].start)/h.step), len(h.buckets))
+}
+
+// bucketOf 返回第 line 行所在的桶：该行及之前最后一条有时间戳的记录的桶（points 按行号升序）
+func (h *histogram) bucketOf(points []timePoint, line int) int {
+	if len(points) == 0 {
+		return 0
+	}
+	k := 0
+	for i, p := range points {
+		if p.line > line {
+			break
+		}
+		k = i
+	}
+	return h.index(points[k].t)
+}
+
+// barHeights 返回柱子中各等级占的行数
+// 有记录的等级至少占一行，这样少量的错误在大量 INFO 中也看得见；超出图表高度时从占行最多的等级中扣除
+func (h *histogram) barHeights(b timeBucket, rows int) []int {
+	heights := make([]int, len(histogramLevels))
+	if h.max == 0 {
+		return heights
+	}
+	sum := 0
+	for k, n := range b.counts {
+		if n > 0 {
+			heights[k] = int(math.Round(float64(n) * float64(rows) / float64(h.max)))
+			if heights[k] < 1 {
+				heights[k] = 1
+			}
+			sum += heights[k]
+		}
+	}
+	for ; sum > rows; sum-- {
+		largest := 0
+		for k := range heights {
+			if heights[k] > heights[largest] {
+				largest = k
+			}
+		}
+		heights[largest]--
+	}
+	return heights
+}
+
+// levelCell 返回第 k 个等级的柱子单元
+func levelCell(k, width int) string {
+	code := activeTheme.Muted
+	if name := histogramLevels[k]; name != "" {
+		item, _ := activeTheme.item(name)
+		code = *item
+	}
+	ch := "█"
+	if !colorOutput {
+		ch = histogramShades[k]
+	}
+	return paint(code, strings.Repeat(ch, width))
+}
+
+// levelLabel 返回等级的显示名称
+func levelLabel(k int) string {
+	if histogramLevels[k] == "" {
+		return "其他"
+	}
+	return strings.ToUpper(histogramLevels[k])
+}
+
+// stepLabel 返回桶大小的显示文本
+func stepLabel(step time.Duration) string {
+	switch {
+	case step >= 24*time.Hour:
+		return fmt.Sprintf("%d 天", int(step/(24*time.Hour)))
+	case step >= time.Hour:
+		return fmt.Sprintf("%d 小时", int(step/time.Hour))
+	}
+	return fmt.Sprintf("%d 分钟", int(step/time.Minute))
+}
+
+// timeLabelLayout 横轴时间标签的格式：跨天时显示日期
+func (h *histogram) timeLabelLayout() string {
+	first, last := h.buckets[0].start, h.buckets[len(h.buckets)-1].start
+	switch {
+	case h.step >= 24*time.Hour:
+		return "01-02"
+	case first.Year() != 0 && first.YearDay() != last.YearDay():
+		return "01-02 15:04"
+	}
+	return "15:04"
+}
+
+// render 绘制柱状图：rows 行的图表、横轴和时间标签，cursor 为光标所在的桶（-1 表示不显示光标）
+func (h *histogram) render(width, rows, cursor int) []string {
+	if len(h.buckets) == 0 {
+		return nil
+	}
+	cell := (width - histogramGutter - 1) / len(h.buckets)
+	if cell > histogramMaxBar {
+		cell = histogramMaxBar
+	}
+	if cell < 1 {
+		cell = 1
+	}
+	// 桶太多时只画放得下的部分，并让光标所在的桶可见
+	buckets := h.buckets
+	if shown := (width - histogramGutter - 1) / cell; len(buckets) > shown {
+		if shown < 1 {
+			shown = 1
+		}
+		offset := 0
+		if cursor >= shown {
+			offset = cursor - shown + 1
+		}
+		buckets = buckets[offset : offset+shown]
+		cursor -= offset
+	}
+	bar := cell
+	if cell > 1 {
+		bar = cell - 1 // 柱子之间留一列
+	}
+
+	heights := make([][]int, len(buckets))
+	for i, b := range buckets {
+		heights[i] = h.barHeights(b, rows)
+	}
+
+	var lines []string
+	for r := rows - 1; r >= 0; r-- {
+		var sb strings.Builder
+		switch r {
+		case rows - 1:
+			sb.WriteString(paint(activeTheme.Gutter, fmt.Sprintf("%6d", h.max)) + " ┤")
+		case 0:
+			sb.WriteString(paint(activeTheme.Gutter, fmt.Sprintf("%6d", 0)) + " ┤")
+		default:
+			sb.WriteString("       │")
+		}
+		for i := range buckets {
+			// 自下而上找到第 r 行所属的等级
+			level, sum := -1, 0
+			for k, n := range heights[i] {
+				sum += n
+				if r < sum {
+					level = k
+					break
+				}
+			}
+			if level < 0 {
+				sb.WriteString(strings.Repeat(" ", cell))
+				continue
+			}
+			sb.WriteString(levelCell(level, bar) + strings.Repeat(" ", cell-bar))
+		}
+		lines = append(lines, sb.String())
+	}
+
+	// 横轴，光标所在的桶下方显示 ▲
+	var axis strings.Builder
+	axis.WriteString("       └")
+	for i := range buckets {
+		if i == cursor {
+			axis.WriteString(paint(activeTheme.Search, "▲") + strings.Repeat("─", cell-1))
+		} else {
+			axis.WriteString(strings.Repeat("─", cell))
+		}
+	}
+	lines = append(lines, axis.String())
+
+	// 时间标签，间隔足够放下标签文本
+	layout := h.timeLabelLayout()
+	every := (len(layout) + 2 + cell - 1) / cell
+	labels := []byte(strings.Repeat(" ", histogramGutter+len(buckets)*cell+len(layout)))
+	for i := 0; i < len(buckets); i += every {
+		col := histogramGutter + i*cell
+		if col+len(layout) > width {
+			break
+		}
+		copy(labels[col:], buckets[i].start.Format(layout))
+	}
+	lines = append(lines, paint(activeTheme.Muted, strings.TrimRight(string(labels), " ")))
+	return lines
+}
+
+// describe 返回第 i 个桶的时间范围和各等级的记录数
+func (h *histogram) describe(i int) string {
+	b := h.buckets[i]
+	end := b.start.Add(h.step)
+	text := fmt.Sprintf("%s – %s  ", formatTime(b.start), end.Format("15:04:05"))
+	if b.total == 0 {
+		return text + paint(activeTheme.Muted, "没有记录")
+	}
+	text += fmt.Sprintf("%d 条记录", b.total)
+	for k, n := range b.counts {
+		if n > 0 {
+			text += "  " + levelCell(k, 1) + fmt.Sprintf(" %s %d", levelLabel(k), n)
+		}
+	}
+	return text
+}
+
+// legend 返回图例，只列出出现过的等级
+func (h *histogram) legend() string {
+	var parts []string
+	for k := range histogramLevels {
+		for _, b := range h.buckets {
+			if b.counts[k] > 0 {
+				parts = append(parts, levelCell(k, 1)+" "+levelLabel(k))
+				break
+			}
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+// title 返回柱状图的标题
+func (h *histogram) title() string {
+	return fmt.Sprintf("每 %s的记录数（共 %d 条有时间戳的记录）", stepLabel(h.step), h.records)
+}
+
+// collectTimePoints 读取整个输入，返回每条有时间戳的记录（按记录首行计算）
+func collectTimePoints(reader io.Reader) ([]timePoint, error) {
+	splitter, err := newRecordSplitter()
+	if err != nil {
+		return nil, err
+	}
+	scanner := newLogScanner(reader)
+	var points []timePoint
+	for lineNum := 0; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if !splitter.isStart(line) {
+			continue
+		}
+		if p, ok := newTimePoint(line, lineNum); ok {
+			points = append(points, p)
+		}
+	}
+	return dropUndated(points), scanner.Err()
+}
+
+// timePointsInFile 在分页器中读取文件，返回 keep 为真的有时间戳的记录
+func timePointsInFile(filePath string, records recordIndex, keep func(line int) bool) ([]timePoint, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := newLogScanner(file)
+	var points []timePoint
+	for i := 0; i < records.total && scanner.Scan(); i++ {
+		if !records.isStart(i) || !keep(i) {
+			continue
+		}
+		if p, ok := newTimePoint(scanner.Text(), i); ok {
+			points = append(points, p)
+		}
+	}
+	return dropUndated(points), scanner.Err()
+}
+
+// histogramJSON lg histogram --json 输出的一个桶
+type histogramJSON struct {
+	Start  string         `json:"start"`
+	Total  int            `json:"total"`
+	Levels map[string]int `json:"levels"`
+}
+
+// printHistogram 输出按时间分桶的柱状图（lg histogram），jsonOutput 时输出各桶的记录数
+func printHistogram(reader io.Reader, width int) error {
+	points, err := collectTimePoints(reader)
+	if err != nil {
+		return err
+	}
+	h := newHistogram(points, width-histogramGutter-1)
+	if jsonOutput {
+		buckets := make([]histogramJSON, 0, len(h.buckets))
+		for _, b := range h.buckets {
+			levels := map[string]int{}
+			for k, n := range b.counts {
+				if n > 0 {
+					name := histogramLevels[k]
+					if name == "" {
+						name = "none"
+					}
+					levels[name] = n
+				}
+			}
+			buckets = append(buckets, histogramJSON{Start: b.start.Format(time.RFC3339), Total: b.total, Levels: levels})
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			StepSeconds int             `json:"step_seconds"`
+			Buckets     []histogramJSON `json:"buckets"`
+		}{int(h.step / time.Second), buckets})
+	}
+
+	if len(h.buckets) == 0 {
+		fmt.Println("没有带时间戳的记录")
+		return nil
+	}
+	fmt.Printf("%s\n\n", paint(activeTheme.Title, h.title()))
+	for _, line := range h.render(width, histogramRows, -1) {
+		fmt.Println(line)
+	}
+	fmt.Printf("\n%s\n", h.legend())
+	return nil
+}
+
+// showHistogram 显示柱状图（分页器中按 H 或输入 :histogram），←/→ 移动光标，
+// 返回选中的桶，按 q/Esc 退出时返回 -1
+func showHistogram(h *histogram, cursor, width, height int) int {
+	for {
+		rows := height - 8
+		if rows < 3 {
+			rows = 3
+		}
+
+		fmt.Print("\033[2J\033[H")
+		fmt.Print(paint(activeTheme.Title, "=== "+h.title()+" ===") + "\r\n\r\n")
+		for _, line := range h.render(width, rows, cursor) {
+			fmt.Print(line + "\r\n")
+		}
+		fmt.Print("\r\n" + truncateWidth(h.describe(cursor), width-1) + "\r\n")
+		fmt.Print(h.legend() + "\r\n")
+		fmt.Printf("\033[%d;1H%s", height, paint(activeTheme.Muted, "←/→ h/l 移动  n/N 下一个/上一个有错误的时间  Enter 跳转到该时间  q/Esc 返回"))
+
+		key, err := keyInput.nextKey()
+		if err != nil {
+			return -1
+		}
+		switch key.name() {
+		case "h", "left":
+			if cursor > 0 {
+				cursor--
+			}
+		case "l", "right":
+			if cursor < len(h.buckets)-1 {
+				cursor++
+			}
+		case "g", "0", "home":
+			cursor = 0
+		case "G", "$", "end":
+			cursor = len(h.buckets) - 1
+		case "n", "N":
+			delta := 1
+			if key.name() == "N" {
+				delta = -1
+			}
+			for i := cursor + delta; i >= 0 && i < len(h.buckets); i += delta {
+				if h.buckets[i].counts[0] > 0 { // error 是 histogramLevels 的第一项
+					cursor = i
+					break
+				}
+			}
+		case "enter":
+			return cursor
+		case "q", "esc":
+			return -1
+		}
+	}
+}
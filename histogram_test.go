@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewHistogramLongSpan(t *testing.T) {
+	base := time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)
+	var points []timePoint
+	for i := 0; i < 48; i++ {
+		// 每月一条记录，共四年，7 天一个桶也远远放不下
+		points = append(points, timePoint{t: base.AddDate(0, i, 0), level: 2, line: i})
+	}
+	const maxBuckets = 10
+	h := newHistogram(points, maxBuckets)
+	if len(h.buckets) > maxBuckets {
+		t.Fatalf("得到 %d 个桶，超过上限 %d", len(h.buckets), maxBuckets)
+	}
+	total := 0
+	for _, b := range h.buckets {
+		total += b.total
+	}
+	if total != len(points) {
+		t.Errorf("各桶合计 %d 条记录，期望 %d", total, len(points))
+	}
+	// 每条记录都在自己的时间范围内，不会被挤到最后一个桶
+	for _, p := range points {
+		b := h.buckets[h.index(p.t)]
+		if p.t.Before(b.start) || !p.t.Before(b.start.Add(h.step)) {
+			t.Errorf("%s 落在 %s 开始的桶中（桶大小 %s）", p.t, b.start, h.step)
+		}
+	}
+}
+
+func TestDropUndated(t *testing.T) {
+	clock := time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC)
+	dated := time.Date(2025, 1, 1, 10, 5, 0, 0, time.UTC)
+	mixed := dropUndated([]timePoint{{t: clock}, {t: dated, line: 1}})
+	if len(mixed) != 1 || mixed[0].line != 1 {
+		t.Errorf("有带日期的时间戳时应只保留带日期的记录，得到 %v", mixed)
+	}
+	if only := dropUndated([]timePoint{{t: clock}, {t: clock.Add(time.Minute)}}); len(only) != 2 {
+		t.Errorf("只有时刻时应保留全部记录，得到 %d 条", len(only))
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// historyLimit 历史记录保留的最大条目数（搜索和命令合计）
+const historyLimit = 500
+
+// promptHistory 搜索和命令的历史记录
+// 每条记录带有提示符前缀（"/panic"、":100"），保存在同一个文件中，跨会话共享
+type promptHistory struct {
+	path    string   // 保存位置，为空时只在内存中记录
+	entries []string // 从旧到新
+}
+
+// historyPath 返回历史文件的位置：$XDG_STATE_HOME/loglens/history，
+// 未设置时为 ~/.local/state/loglens/history
+func historyPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "loglens", "history")
+}
+
+// loadPromptHistory 读取历史文件，文件不存在或无法读取时返回空的历史记录
+func loadPromptHistory(path string) *promptHistory {
+	return &promptHistory{path: path, entries: readHistoryFile(path)}
+}
+
+// readHistoryFile 读取历史文件中最新的 historyLimit 条记录，文件不存在或无法读取时返回 nil
+func readHistoryFile(path string) []string {
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); len(line) > 1 {
+			entries = append(entries, line)
+		}
+	}
+	if len(entries) > historyLimit {
+		entries = entries[len(entries)-historyLimit:]
+	}
+	return entries
+}
+
+// list 返回指定提示符的历史内容（不含前缀），从旧到新
+func (h *promptHistory) list(prefix string) []string {
+	var items []string
+	for _, entry := range h.entries {
+		if strings.HasPrefix(entry, prefix) {
+			items = append(items, entry[len(prefix):])
+		}
+	}
+	return items
+}
+
+// add 记录一条输入并保存到文件
+// 相同的记录只保留最新的一条，超过上限时丢弃最旧的记录；
+// 保存前重新读取文件，保留同时打开的其他分页器在此期间写入的记录
+func (h *promptHistory) add(prefix, text string) {
+	if strings.TrimSpace(text) == "" || strings.ContainsAny(text, "\r\n") {
+		return
+	}
+	if h.path != "" {
+		if entries := readHistoryFile(h.path); entries != nil {
+			h.entries = entries
+		}
+	}
+	entry := prefix + text
+	kept := h.entries[:0]
+	for _, e := range h.entries {
+		if e != entry {
+			kept = append(kept, e)
+		}
+	}
+	h.entries = append(kept, entry)
+	if len(h.entries) > historyLimit {
+		h.entries = h.entries[len(h.entries)-historyLimit:]
+	}
+	h.save()
+}
+
+// save 将历史记录写入文件（先写临时文件再重命名，避免写入中断时损坏原文件，
+// 临时文件名各不相同，多个分页器同时保存时不会互相覆盖写了一半的文件）
+// 历史记录不影响主要功能，保存失败时忽略
+func (h *promptHistory) save() {
+	if h.path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.WriteString(strings.Join(h.entries, "\n") + "\n")
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), h.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// jsonColorTheme JSON 语法着色使用的 ANSI 颜色代码
+type jsonColorTheme struct {
+	Key     string
+	String  string
+	Number  string
+	Bool    string
+	Null    string
+	Decoded string // 内嵌 JSON 展开标记
+}
+
+// 预设 JSON 配色方案
+var jsonThemeMap = map[string]jsonColorTheme{
+	"default": {Key: "34", String: "32", Number: "33", Bool: "35", Null: "90", Decoded: "90;3"},
+	"bright":  {Key: "94", String: "92", Number: "93", Bool: "95", Null: "37", Decoded: "37;3"},
+	"mono":    {Key: "1", String: "", Number: "", Bool: "", Null: "2", Decoded: "2;3"},
+}
+
+// convertJSONTheme 解析 JSON 配色参数
+// 支持预设名称（如 default、bright），也支持以逗号分隔的覆盖项，
+// 例如 "key=cyan,number=#ffaf00"，未指定的项沿用 base（当前配色方案中的 JSON 配色）
+func convertJSONTheme(spec string, base jsonColorTheme) (jsonColorTheme, error) {
+	if theme, ok := jsonThemeMap[spec]; ok {
+		return theme, nil
+	}
+
+	theme := base
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return theme, fmt.Errorf("未知的 JSON 配色方案: %s", item)
+		}
+		code, err := convertLineNumColor(strings.TrimSpace(value))
+		if err != nil {
+			return theme, err
+		}
+		switch strings.TrimSpace(name) {
+		case "key":
+			theme.Key = code
+		case "string":
+			theme.String = code
+		case "number":
+			theme.Number = code
+		case "bool":
+			theme.Bool = code
+		case "null":
+			theme.Null = code
+		case "decoded":
+			theme.Decoded = code
+		default:
+			return theme, fmt.Errorf("未知的 JSON 配色项: %s", name)
+		}
+	}
+	return theme, nil
+}
+
+// jsonSyntaxSpans 扫描 JSON 文本，返回键、字符串、数字、布尔值和 null 的着色区间
+// 同时适用于单行 JSON 和格式化后的多行 JSON（含 /* ... */ 展开标记）
+func jsonSyntaxSpans(s string) []styleSpan {
+	var spans []styleSpan
+	add := func(start, end int, code string) {
+		if code != "" {
+			spans = append(spans, styleSpan{start: start, end: end, code: code})
+		}
+	}
+
+	for i := 0; i < len(s); {
+		ch := s[i]
+		switch {
+		case ch == '"':
+			end := scanJSONString(s, i)
+			// 字符串后面（跳过空白）紧跟冒号的是键
+			j := end
+			for j < len(s) && (s[j] == ' ' || s[j] == '\t') {
+				j++
+			}
+			if j < len(s) && s[j] == ':' {
+				add(i, end, activeTheme.JSON.Key)
+			} else {
+				add(i, end, activeTheme.JSON.String)
+			}
+			i = end
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				end = len(s)
+			} else {
+				end += i + 4
+			}
+			add(i, end, activeTheme.JSON.Decoded)
+			i = end
+		case ch == '-' || (ch >= '0' && ch <= '9'):
+			end := i + 1
+			for end < len(s) && strings.IndexByte("0123456789.eE+-", s[end]) >= 0 {
+				end++
+			}
+			add(i, end, activeTheme.JSON.Number)
+			i = end
+		case strings.HasPrefix(s[i:], "true"):
+			add(i, i+4, activeTheme.JSON.Bool)
+			i += 4
+		case strings.HasPrefix(s[i:], "false"):
+			add(i, i+5, activeTheme.JSON.Bool)
+			i += 5
+		case strings.HasPrefix(s[i:], "null"):
+			add(i, i+4, activeTheme.JSON.Null)
+			i += 4
+		default:
+			i++
+		}
+	}
+	return spans
+}
+
+// scanJSONString 从起始引号位置扫描到字符串结束（返回结束引号之后的位置）
+func scanJSONString(s string, start int) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// colorizeJSON 为 JSON 文本添加语法着色和固定高亮
+func colorizeJSON(s string) string {
+	return renderSpans(s, append(jsonSyntaxSpans(s), highlightSpans(s)...))
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// 内嵌 JSON 展开相关常量
+const (
+	// maxEmbeddedDepth 内嵌 JSON 递归展开的最大层数，防止异常数据导致无限展开
+	maxEmbeddedDepth = 8
+	// minBase64Len 尝试按 base64 解码的最小字符串长度，过短的字符串误判概率太高
+	minBase64Len = 8
+
+	markerEmbeddedJSON   = "/* 内嵌 JSON 已展开 */"
+	markerEmbeddedBase64 = "/* base64 JSON 已展开 */"
+)
+
+// base64 解码尝试顺序：标准、无填充、URL 安全、URL 安全无填充
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.RawStdEncoding,
+	base64.URLEncoding,
+	base64.RawURLEncoding,
+}
+
+// jsonKind JSON 节点类型
+type jsonKind int
+
+const (
+	jsonNull jsonKind = iota
+	jsonBool
+	jsonNumber
+	jsonString
+	jsonObject
+	jsonArray
+)
+
+// jsonNode 保留原始键顺序和数字原文的 JSON 节点
+// 不解析为 interface{}，避免键被按字母重排、大整数（如 19 位订单号）被转换为 float64 丢失精度
+type jsonNode struct {
+	kind   jsonKind
+	text   string      // 标量值：字符串内容、数字原文或 true/false
+	keys   []string    // 对象的键（按原始顺序）
+	values []*jsonNode // 对象的值或数组的元素
+}
+
+// parseJSON 解析 JSON 文本为 jsonNode，要求整个文本是单个 JSON 值
+func parseJSON(s string) (*jsonNode, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	node, err := decodeJSONNode(dec)
+	if err != nil {
+		return nil, err
+	}
+	// 不允许存在多余的内容
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("JSON 值之后存在多余内容")
+	}
+	return node, nil
+}
+
+// decodeJSONNode 从解码器中读取一个完整的 JSON 值
+func decodeJSONNode(dec *json.Decoder) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			node := &jsonNode{kind: jsonObject}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ := keyTok.(string)
+				value, err := decodeJSONNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, key)
+				node.values = append(node.values, value)
+			}
+			if _, err := dec.Token(); err != nil { // 读取 '}'
+				return nil, err
+			}
+			return node, nil
+		case '[':
+			node := &jsonNode{kind: jsonArray}
+			for dec.More() {
+				item, err := decodeJSONNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				node.values = append(node.values, item)
+			}
+			if _, err := dec.Token(); err != nil { // 读取 ']'
+				return nil, err
+			}
+			return node, nil
+		}
+		return nil, fmt.Errorf("意外的分隔符 %v", t)
+	case string:
+		return &jsonNode{kind: jsonString, text: t}, nil
+	case json.Number:
+		return &jsonNode{kind: jsonNumber, text: t.String()}, nil
+	case bool:
+		return &jsonNode{kind: jsonBool, text: strconv.FormatBool(t)}, nil
+	case nil:
+		return &jsonNode{kind: jsonNull}, nil
+	}
+	return nil, fmt.Errorf("无法识别的 JSON 值: %v", tok)
+}
+
+// prettyJSON 将 JSON 节点格式化为缩进文本
+// 字符串值本身是 JSON（或 base64 编码的 JSON）时会原地展开，并加注释标记
+func prettyJSON(node *jsonNode) string {
+	var sb strings.Builder
+	writePrettyJSON(&sb, node, "", 0)
+	return sb.String()
+}
+
+// writePrettyJSON 递归写出 JSON 节点，indent 为当前缩进，depth 为已展开的内嵌层数
+func writePrettyJSON(sb *strings.Builder, node *jsonNode, indent string, depth int) {
+	switch node.kind {
+	case jsonObject:
+		if len(node.keys) == 0 {
+			sb.WriteString("{}")
+			return
+		}
+		sb.WriteString("{\n")
+		for i, k := range node.keys {
+			sb.WriteString(indent + "  ")
+			sb.WriteString(marshalJSONString(k))
+			sb.WriteString(": ")
+			writePrettyJSON(sb, node.values[i], indent+"  ", depth)
+			if i < len(node.keys)-1 {
+				sb.WriteByte(',')
+			}
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(indent + "}")
+	case jsonArray:
+		if len(node.values) == 0 {
+			sb.WriteString("[]")
+			return
+		}
+		sb.WriteString("[\n")
+		for i, item := range node.values {
+			sb.WriteString(indent + "  ")
+			writePrettyJSON(sb, item, indent+"  ", depth)
+			if i < len(node.values)-1 {
+				sb.WriteByte(',')
+			}
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(indent + "]")
+	case jsonString:
+		if depth < maxEmbeddedDepth {
+			if inner, marker, ok := decodeEmbeddedJSON(node.text); ok {
+				sb.WriteString(marker + " ")
+				writePrettyJSON(sb, inner, indent, depth+1)
+				return
+			}
+		}
+		sb.WriteString(marshalJSONString(node.text))
+	case jsonNull:
+		sb.WriteString("null")
+	default:
+		// 数字和布尔值直接输出原文
+		sb.WriteString(node.text)
+	}
+}
+
+// decodeEmbeddedJSON 检查字符串值是否本身是 JSON 对象/数组，或 base64 编码的 JSON
+// 返回解析结果和用于标记的注释
+func decodeEmbeddedJSON(s string) (*jsonNode, string, bool) {
+	trimmed := strings.TrimSpace(s)
+	if v, ok := parseJSONContainer(trimmed); ok {
+		return v, markerEmbeddedJSON, true
+	}
+
+	if len(trimmed) < minBase64Len {
+		return nil, "", false
+	}
+	for _, enc := range base64Encodings {
+		decoded, err := enc.DecodeString(trimmed)
+		if err != nil {
+			continue
+		}
+		if v, ok := parseJSONContainer(strings.TrimSpace(string(decoded))); ok {
+			return v, markerEmbeddedBase64, true
+		}
+	}
+	return nil, "", false
+}
+
+// parseJSONContainer 仅当文本是 JSON 对象或数组时才解析
+// 纯数字、布尔等标量字符串不展开，避免把 "123" 之类的值当作内嵌 JSON
+func parseJSONContainer(s string) (*jsonNode, bool) {
+	if len(s) < 2 || (s[0] != '{' && s[0] != '[') {
+		return nil, false
+	}
+	node, err := parseJSON(s)
+	if err != nil {
+		return nil, false
+	}
+	return node, true
+}
+
+// marshalJSONString 将字符串编码为 JSON 字符串字面量（不转义 HTML 字符）
+func marshalJSONString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathStep JSON 路径中的一步：对象键或数组下标
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// jsonPath 一条 jq 风格的路径，例如 .request.headers["x-trace-id"]
+type jsonPath struct {
+	expr  string
+	steps []pathStep
+}
+
+// parseJSONPaths 解析以逗号分隔的多条路径，例如 ".user_id,.latency"
+func parseJSONPaths(spec string) ([]jsonPath, error) {
+	var paths []jsonPath
+	for _, expr := range splitPathList(spec) {
+		p, err := parseJSONPath(expr)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("路径表达式为空")
+	}
+	return paths, nil
+}
+
+// splitPathList 按顶层逗号拆分路径列表（忽略引号和方括号内的逗号）
+func splitPathList(spec string) []string {
+	var parts []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(spec); i++ {
+		ch := spec[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '[':
+			depth++
+		case ch == ']':
+			depth--
+		case ch == ',' && depth == 0:
+			parts = append(parts, spec[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, spec[start:])
+
+	var result []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// parseJSONPath 解析单条路径
+// 支持 .a.b、.a[0]、.a["x-y"]、.a['x-y']，单独的 . 表示整个值
+func parseJSONPath(expr string) (jsonPath, error) {
+	p := jsonPath{expr: expr}
+	if !strings.HasPrefix(expr, ".") {
+		return p, fmt.Errorf("路径必须以 . 开头: %s", expr)
+	}
+
+	for i := 0; i < len(expr); {
+		switch expr[i] {
+		case '.':
+			i++
+			start := i
+			for i < len(expr) && expr[i] != '.' && expr[i] != '[' {
+				i++
+			}
+			if i > start {
+				p.steps = append(p.steps, pathStep{key: expr[start:i]})
+			}
+		case '[':
+			// 引号中的 ] 和转义的引号不作为结束符
+			if i+1 < len(expr) && (expr[i+1] == '"' || expr[i+1] == '\'') {
+				quote := expr[i+1]
+				closeQuote := -1
+				for j := i + 2; j < len(expr); j++ {
+					if expr[j] == '\\' {
+						j++
+					} else if expr[j] == quote {
+						closeQuote = j
+						break
+					}
+				}
+				if closeQuote < 0 || closeQuote+1 >= len(expr) || expr[closeQuote+1] != ']' {
+					return p, fmt.Errorf("路径中的引号未闭合: %s", expr)
+				}
+				key, err := unquotePathKey(expr[i+2:closeQuote], quote)
+				if err != nil {
+					return p, fmt.Errorf("路径中的键名无效: %s", expr[i:closeQuote+2])
+				}
+				p.steps = append(p.steps, pathStep{key: key})
+				i = closeQuote + 2
+				continue
+			}
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return p, fmt.Errorf("路径中的 [ 未闭合: %s", expr)
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(expr[i+1 : i+end]))
+			if err != nil {
+				return p, fmt.Errorf("无效的数组下标: %s", expr[i:i+end+1])
+			}
+			p.steps = append(p.steps, pathStep{index: index, isIndex: true})
+			i += end + 1
+		default:
+			return p, fmt.Errorf("无法解析的路径: %s", expr)
+		}
+	}
+	return p, nil
+}
+
+// unquotePathKey 按 JSON 字符串的规则处理引号中键名的转义符（如 \"、\u00e9），
+// 单引号中还可以用 \' 表示单引号
+func unquotePathKey(raw string, quote byte) (string, error) {
+	if quote == '\'' {
+		var sb strings.Builder
+		for i := 0; i < len(raw); i++ {
+			switch {
+			case raw[i] == '\\' && i+1 < len(raw) && raw[i+1] == '\'':
+				sb.WriteByte('\'')
+				i++
+			case raw[i] == '\\' && i+1 < len(raw):
+				sb.WriteString(raw[i : i+2])
+				i++
+			case raw[i] == '"':
+				sb.WriteString(`\"`)
+			default:
+				sb.WriteByte(raw[i])
+			}
+		}
+		raw = sb.String()
+	}
+	var key string
+	err := json.Unmarshal([]byte(`"`+raw+`"`), &key)
+	return key, err
+}
+
+// lookup 在 JSON 节点中查找路径对应的值
+// 遇到内容为 JSON 的字符串字段时会自动展开后继续查找
+func (p jsonPath) lookup(node *jsonNode) (*jsonNode, bool) {
+	for _, step := range p.steps {
+		if node.kind == jsonString {
+			inner, _, ok := decodeEmbeddedJSON(node.text)
+			if !ok {
+				return nil, false
+			}
+			node = inner
+		}
+
+		if step.isIndex {
+			if node.kind != jsonArray {
+				return nil, false
+			}
+			index := step.index
+			if index < 0 {
+				index += len(node.values) // 负数下标从末尾开始计数
+			}
+			if index < 0 || index >= len(node.values) {
+				return nil, false
+			}
+			node = node.values[index]
+			continue
+		}
+
+		if node.kind != jsonObject {
+			return nil, false
+		}
+		found := false
+		for i, k := range node.keys {
+			if k == step.key {
+				node = node.values[i]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// compactJSON 将 JSON 节点输出为紧凑的单行文本
+func compactJSON(node *jsonNode) string {
+	var sb strings.Builder
+	writeCompactJSON(&sb, node)
+	return sb.String()
+}
+
+// writeCompactJSON 递归写出紧凑格式的 JSON 节点
+func writeCompactJSON(sb *strings.Builder, node *jsonNode) {
+	switch node.kind {
+	case jsonObject:
+		sb.WriteByte('{')
+		for i, k := range node.keys {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(marshalJSONString(k))
+			sb.WriteByte(':')
+			writeCompactJSON(sb, node.values[i])
+		}
+		sb.WriteByte('}')
+	case jsonArray:
+		sb.WriteByte('[')
+		for i, item := range node.values {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeCompactJSON(sb, item)
+		}
+		sb.WriteByte(']')
+	case jsonString:
+		sb.WriteString(marshalJSONString(node.text))
+	case jsonNull:
+		sb.WriteString("null")
+	default:
+		sb.WriteString(node.text)
+	}
+}
+
+// extractValues 从一行 JSON 中提取多条路径的值，以制表符分隔
+// 字符串值输出原文（类似 jq -r），不存在的路径输出空字段；非 JSON 行返回 false
+func extractValues(line string, paths []jsonPath) (string, bool) {
+	node, err := parseJSON(line)
+	if err != nil {
+		return "", false
+	}
+
+	values := make([]string, len(paths))
+	for i, p := range paths {
+		value, ok := p.lookup(node)
+		switch {
+		case !ok:
+			values[i] = ""
+		case value.kind == jsonString:
+			values[i] = value.text
+		default:
+			values[i] = compactJSON(value)
+		}
+	}
+	return strings.Join(values, "\t"), true
+}
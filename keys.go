@@ -0,0 +1,314 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// escTimeout 收到 ESC 后等待后续字节的时间，超时则视为单独的 ESC 键
+const escTimeout = 50 * time.Millisecond
+
+// keyCode 解码后的按键类型
+type keyCode int
+
+const (
+	keyUnknown keyCode = iota // 无法识别的序列（整体丢弃）
+	keyRune                   // 普通字符或控制字符，见 keyEvent.r
+	keyEscape
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyDelete
+	keyWheelUp
+	keyWheelDown
+	keyPasteStart // 括号粘贴模式：粘贴内容开始
+	keyPasteEnd   // 括号粘贴模式：粘贴内容结束
+)
+
+// keyEvent 一次按键
+type keyEvent struct {
+	code keyCode
+	r    rune // code 为 keyRune 时的字符
+}
+
+// 特殊按键的名称，用于按键绑定
+var keyCodeNames = map[keyCode]string{
+	keyEscape:     "esc",
+	keyUp:         "up",
+	keyDown:       "down",
+	keyLeft:       "left",
+	keyRight:      "right",
+	keyPageUp:     "pgup",
+	keyPageDown:   "pgdn",
+	keyHome:       "home",
+	keyEnd:        "end",
+	keyDelete:     "delete",
+	keyWheelUp:    "wheel-up",
+	keyWheelDown:  "wheel-down",
+	keyPasteStart: "paste-start",
+	keyPasteEnd:   "paste-end",
+}
+
+// name 返回按键的名称，例如 "j"、"ctrl+f"、"space"、"pgdn"
+func (k keyEvent) name() string {
+	if k.code != keyRune {
+		return keyCodeNames[k.code]
+	}
+	switch {
+	case k.r == ' ':
+		return "space"
+	case k.r == '\r' || k.r == '\n':
+		return "enter"
+	case k.r == '\t':
+		return "tab"
+	case k.r == 127 || k.r == 8:
+		return "backspace"
+	case k.r < 0x20:
+		return "ctrl+" + string(rune(k.r+'a'-1))
+	}
+	return string(k.r)
+}
+
+// validKeyName 判断配置中的按键名称是否对应实际能按出的按键：
+// 单个字符、ctrl+<字母>，或 space、enter、esc、pgdn 等命名按键（粘贴标记除外）
+func validKeyName(name string) bool {
+	if letter, ok := strings.CutPrefix(name, "ctrl+"); ok {
+		if len(letter) != 1 || letter[0] < 'a' || letter[0] > 'z' {
+			return false
+		}
+		// ctrl+h、ctrl+i、ctrl+j、ctrl+m 与 backspace、tab、enter 是同一个按键
+		return keyEvent{code: keyRune, r: rune(letter[0] - 'a' + 1)}.name() == name
+	}
+	switch name {
+	case "space", "enter", "tab", "backspace":
+		return true
+	case keyCodeNames[keyPasteStart], keyCodeNames[keyPasteEnd]:
+		return false
+	}
+	for _, n := range keyCodeNames {
+		if n == name {
+			return true
+		}
+	}
+	r, size := utf8.DecodeRuneInString(name)
+	return size == len(name) && r != utf8.RuneError && keyEvent{code: keyRune, r: r}.name() == name
+}
+
+// parseKey 从字节流开头解码一个按键，返回按键和消费的字节数
+// 字节不足以构成完整序列时返回 needMore；final 为 true 表示不会再有后续字节（已超时），
+// 此时按已有的字节尽量解码。该函数不依赖终端，可以直接用录制的字节流测试
+func parseKey(buf []byte, final bool) (ev keyEvent, n int, needMore bool) {
+	if len(buf) == 0 {
+		return keyEvent{}, 0, true
+	}
+
+	b := buf[0]
+	if b == 0x1b {
+		return parseEscape(buf, final)
+	}
+	if b < utf8.RuneSelf {
+		return keyEvent{code: keyRune, r: rune(b)}, 1, false
+	}
+
+	// 多字节 UTF-8 字符
+	if !utf8.FullRune(buf) && !final {
+		return keyEvent{}, 0, true
+	}
+	r, size := utf8.DecodeRune(buf)
+	if r == utf8.RuneError && size <= 1 {
+		return keyEvent{code: keyUnknown}, 1, false
+	}
+	return keyEvent{code: keyRune, r: r}, size, false
+}
+
+// parseEscape 解码以 ESC 开头的序列（CSI、SS3 或单独的 ESC）
+func parseEscape(buf []byte, final bool) (keyEvent, int, bool) {
+	if len(buf) == 1 {
+		if !final {
+			return keyEvent{}, 0, true
+		}
+		return keyEvent{code: keyEscape}, 1, false
+	}
+
+	switch buf[1] {
+	case '[':
+		// CSI 序列：ESC [ 参数字节... 终止字节(0x40-0x7E)
+		for i := 2; i < len(buf); i++ {
+			if buf[i] >= 0x40 && buf[i] <= 0x7E {
+				return decodeCSI(string(buf[2:i]), buf[i]), i + 1, false
+			}
+		}
+		if !final {
+			return keyEvent{}, 0, true
+		}
+		return keyEvent{code: keyUnknown}, len(buf), false
+	case 'O':
+		// SS3 序列：ESC O 字母（部分终端的方向键和 Home/End）
+		if len(buf) < 3 {
+			if !final {
+				return keyEvent{}, 0, true
+			}
+			return keyEvent{code: keyUnknown}, len(buf), false
+		}
+		return decodeCSI("", buf[2]), 3, false
+	}
+	// ESC 后跟普通字符（如 Alt+键），只把 ESC 作为单独的按键
+	return keyEvent{code: keyEscape}, 1, false
+}
+
+// decodeCSI 根据参数和终止字节识别按键
+func decodeCSI(params string, final byte) keyEvent {
+	// SGR 鼠标事件：ESC [ < 按钮;列;行 M/m
+	if strings.HasPrefix(params, "<") && (final == 'M' || final == 'm') {
+		fields := strings.Split(params[1:], ";")
+		button, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return keyEvent{code: keyUnknown}
+		}
+		// 去掉 Shift/Alt/Ctrl 修饰位
+		switch button &^ (4 | 8 | 16) {
+		case 64:
+			return keyEvent{code: keyWheelUp}
+		case 65:
+			return keyEvent{code: keyWheelDown}
+		}
+		return keyEvent{code: keyUnknown}
+	}
+
+	switch final {
+	case 'A':
+		return keyEvent{code: keyUp}
+	case 'B':
+		return keyEvent{code: keyDown}
+	case 'C':
+		return keyEvent{code: keyRight}
+	case 'D':
+		return keyEvent{code: keyLeft}
+	case 'H':
+		return keyEvent{code: keyHome}
+	case 'F':
+		return keyEvent{code: keyEnd}
+	case '~':
+		// ESC [ 数字 ~ 形式的编辑键，可能带 ;修饰符
+		num, _, _ := strings.Cut(params, ";")
+		switch num {
+		case "1", "7":
+			return keyEvent{code: keyHome}
+		case "4", "8":
+			return keyEvent{code: keyEnd}
+		case "3":
+			return keyEvent{code: keyDelete}
+		case "5":
+			return keyEvent{code: keyPageUp}
+		case "6":
+			return keyEvent{code: keyPageDown}
+		case "200":
+			return keyEvent{code: keyPasteStart}
+		case "201":
+			return keyEvent{code: keyPasteEnd}
+		}
+	}
+	return keyEvent{code: keyUnknown}
+}
+
+// pagerAction 分页器中可以绑定到按键的操作
+type pagerAction string
+
+const (
+	actionQuit         pagerAction = "quit"
+	actionLineDown     pagerAction = "line-down"
+	actionLineUp       pagerAction = "line-up"
+	actionPageDown     pagerAction = "page-down"
+	actionPageUp       pagerAction = "page-up"
+	actionHalfPageDown pagerAction = "half-page-down"
+	actionHalfPageUp   pagerAction = "half-page-up"
+	actionTop          pagerAction = "top"
+	actionBottom       pagerAction = "bottom"
+	actionScrollLeft   pagerAction = "scroll-left"
+	actionScrollRight  pagerAction = "scroll-right"
+	actionWheelUp      pagerAction = "wheel-up"
+	actionWheelDown    pagerAction = "wheel-down"
+	actionNextMatch    pagerAction = "next-match"
+	actionPrevMatch    pagerAction = "prev-match"
+	actionCommand      pagerAction = "command"
+	actionSearch       pagerAction = "search"
+	actionFilter       pagerAction = "filter"
+	actionNextRecord   pagerAction = "next-record"
+	actionPrevRecord   pagerAction = "prev-record"
+	actionFormatJSON   pagerAction = "format-json"
+	actionToggleChop   pagerAction = "toggle-chop"
+	actionToggleFold   pagerAction = "toggle-fold"
+	actionFoldAll      pagerAction = "fold-all"
+	actionToggleUniq   pagerAction = "toggle-uniq"
+	actionHistogram    pagerAction = "histogram"
+	actionToggleCase   pagerAction = "toggle-case"
+	actionToggleWord   pagerAction = "toggle-word"
+	actionSuspend      pagerAction = "suspend"
+)
+
+// validActions 所有可以绑定的操作，用于检查配置文件中的按键绑定
+var validActions = map[pagerAction]bool{
+	actionQuit: true, actionLineDown: true, actionLineUp: true,
+	actionPageDown: true, actionPageUp: true, actionHalfPageDown: true, actionHalfPageUp: true,
+	actionTop: true, actionBottom: true, actionScrollLeft: true, actionScrollRight: true,
+	actionWheelUp: true, actionWheelDown: true, actionNextMatch: true, actionPrevMatch: true,
+	actionCommand: true, actionSearch: true, actionFilter: true, actionNextRecord: true, actionPrevRecord: true,
+	actionFormatJSON: true, actionToggleChop: true, actionToggleFold: true, actionFoldAll: true,
+	actionToggleUniq: true, actionHistogram: true, actionToggleCase: true, actionToggleWord: true, actionSuspend: true,
+}
+
+// defaultKeyBindings 默认按键绑定（按键名称 -> 操作）
+var defaultKeyBindings = map[string]pagerAction{
+	"q":          actionQuit,
+	"Q":          actionQuit,
+	"j":          actionLineDown,
+	"J":          actionLineDown,
+	"enter":      actionLineDown,
+	"down":       actionLineDown,
+	"k":          actionLineUp,
+	"K":          actionLineUp,
+	"up":         actionLineUp,
+	"ctrl+f":     actionPageDown,
+	"space":      actionPageDown,
+	"pgdn":       actionPageDown,
+	"ctrl+b":     actionPageUp,
+	"b":          actionPageUp,
+	"pgup":       actionPageUp,
+	"ctrl+d":     actionHalfPageDown,
+	"ctrl+u":     actionHalfPageUp,
+	"g":          actionTop,
+	"home":       actionTop,
+	"G":          actionBottom,
+	"end":        actionBottom,
+	"left":       actionScrollLeft,
+	"right":      actionScrollRight,
+	"wheel-up":   actionWheelUp,
+	"wheel-down": actionWheelDown,
+	"n":          actionNextMatch,
+	"N":          actionPrevMatch,
+	":":          actionCommand,
+	"/":          actionSearch,
+	"&":          actionFilter,
+	"}":          actionNextRecord,
+	"{":          actionPrevRecord,
+	"f":          actionFormatJSON,
+	"F":          actionFormatJSON,
+	"S":          actionToggleChop,
+	"z":          actionToggleFold,
+	"Z":          actionFoldAll,
+	"u":          actionToggleUniq,
+	"H":          actionHistogram,
+	"i":          actionToggleCase,
+	"w":          actionToggleWord,
+	"ctrl+z":     actionSuspend,
+}
+
+// keyBindings 当前生效的按键绑定
+var keyBindings = defaultKeyBindings
@@ -0,0 +1,143 @@
+package main
+
+import "testing"
+
+// decodeStream 按终端分次读到的字节块解码按键：字节不足时等待下一块，
+// 最后一块之后视为超时（final），与 terminalInput 的处理方式相同
+func decodeStream(t *testing.T, chunks ...string) []keyEvent {
+	t.Helper()
+	var events []keyEvent
+	var buf []byte
+	for i, chunk := range chunks {
+		buf = append(buf, chunk...)
+		final := i == len(chunks)-1
+		for len(buf) > 0 {
+			ev, n, needMore := parseKey(buf, final)
+			if needMore {
+				break
+			}
+			if n == 0 {
+				t.Fatalf("parseKey(%q) 没有消费字节", buf)
+			}
+			events = append(events, ev)
+			buf = buf[n:]
+		}
+	}
+	if len(buf) > 0 {
+		t.Fatalf("剩余未解码的字节 %q", buf)
+	}
+	return events
+}
+
+func TestParseKeyRecordedStreams(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string // 录制的字节流，每个元素是一次读取的内容
+		want   []string // 按键名称
+	}{
+		{"PgUp/PgDn", []string{"\x1b[5~\x1b[6~"}, []string{"pgup", "pgdn"}},
+		{"SS3 方向键", []string{"\x1bOA"}, []string{"up"}},
+		{"CSI 方向键", []string{"\x1b[A\x1b[B\x1b[C\x1b[D"}, []string{"up", "down", "right", "left"}},
+		{"Home/End 编辑键", []string{"\x1b[1~\x1b[4~\x1b[H\x1b[F"}, []string{"home", "end", "home", "end"}},
+		{"带修饰符的编辑键", []string{"\x1b[3;5~"}, []string{"delete"}},
+		{"SGR 滚轮", []string{"\x1b[<64;10;5M\x1b[<65;10;5M"}, []string{"wheel-up", "wheel-down"}},
+		{"带 Ctrl 修饰的滚轮", []string{"\x1b[<80;1;1M"}, []string{"wheel-up"}},
+		{"鼠标点击被忽略", []string{"\x1b[<0;10;5M"}, []string{""}},
+		{"单独的 ESC", []string{"\x1b"}, []string{"esc"}},
+		{"ESC 后跟普通字符", []string{"\x1bj"}, []string{"esc", "j"}},
+		{"括号粘贴", []string{"\x1b[200~ab\x1b[201~"}, []string{"paste-start", "a", "b", "paste-end"}},
+		{"CSI 分两次读到", []string{"\x1b[", "5~"}, []string{"pgup"}},
+		{"SGR 鼠标分两次读到", []string{"\x1b[<64;1", "0;5M"}, []string{"wheel-up"}},
+		{"UTF-8 字符分两次读到", []string{"\xe4\xb8", "\xad"}, []string{"中"}},
+		{"控制字符", []string{"\x06\x02\r\t\x7f "}, []string{"ctrl+f", "ctrl+b", "enter", "tab", "backspace", "space"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := decodeStream(t, tt.chunks...)
+			if len(events) != len(tt.want) {
+				t.Fatalf("得到 %d 个按键 %v，期望 %v", len(events), events, tt.want)
+			}
+			for i, ev := range events {
+				if got := ev.name(); got != tt.want[i] {
+					t.Errorf("第 %d 个按键为 %q，期望 %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseKeyNeedMore(t *testing.T) {
+	tests := []struct {
+		name string
+		buf  string
+	}{
+		{"单独的 ESC 未超时", "\x1b"},
+		{"不完整的 CSI", "\x1b[5"},
+		{"不完整的 SGR 鼠标", "\x1b[<64;10"},
+		{"不完整的 SS3", "\x1bO"},
+		{"不完整的 UTF-8", "\xe4\xb8"},
+		{"空输入", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, n, needMore := parseKey([]byte(tt.buf), false); !needMore || n != 0 {
+				t.Errorf("parseKey(%q, false) = n %d, needMore %v，期望等待更多字节", tt.buf, n, needMore)
+			}
+		})
+	}
+}
+
+func TestParseKeyFinal(t *testing.T) {
+	tests := []struct {
+		name string
+		buf  string
+		want keyCode
+		n    int
+	}{
+		{"超时后的单独 ESC", "\x1b", keyEscape, 1},
+		{"超时后不完整的 CSI 整体丢弃", "\x1b[5", keyUnknown, 3},
+		{"超时后不完整的 SS3 整体丢弃", "\x1bO", keyUnknown, 2},
+		{"非法的 UTF-8 字节", "\xff", keyUnknown, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, n, needMore := parseKey([]byte(tt.buf), true)
+			if needMore || ev.code != tt.want || n != tt.n {
+				t.Errorf("parseKey(%q, true) = %v, %d, %v，期望 code %v，消费 %d 字节", tt.buf, ev, n, needMore, tt.want, tt.n)
+			}
+		})
+	}
+}
+
+func TestValidKeyName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"j", true},
+		{"G", true},
+		{"中", true},
+		{"ctrl+f", true},
+		{"space", true},
+		{"enter", true},
+		{"esc", true},
+		{"pgdn", true},
+		{"delete", true},
+		{"wheel-down", true},
+		{"ctrl+h", false}, // 与 backspace 相同
+		{"ctrl+F", false},
+		{"ctrl+1", false},
+		{"C-f", false},
+		{"PageDown", false},
+		{"paste-start", false},
+		{"jj", false},
+		{" ", false},
+		{"\x01", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := validKeyName(tt.name); got != tt.want {
+			t.Errorf("validKeyName(%q) = %v，期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tabWidth 制表符对齐宽度
+const tabWidth = 8
+
+// wideRanges 东亚宽字符（East Asian Wide / Fullwidth）以及常见 emoji 的码点区间
+// 这些字符在终端中占 2 列
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18CFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F251}, {0x1F300, 0x1F64F},
+	{0x1F680, 0x1F6FF}, {0x1F7E0, 0x1F7EB}, {0x1F90C, 0x1F9FF}, {0x1FA70, 0x1FAFF},
+	{0x20000, 0x3FFFD},
+}
+
+// runeWidth 返回字符在终端中占用的列数
+func runeWidth(r rune) int {
+	// 组合字符、格式控制字符（如零宽连接符）不占宽度
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) || (r >= 0x1160 && r <= 0x11FF) {
+		return 0
+	}
+	if r < 0x1100 {
+		return 1
+	}
+	// 二分查找宽字符区间
+	lo, hi := 0, len(wideRanges)-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		switch {
+		case r < wideRanges[mid][0]:
+			hi = mid - 1
+		case r > wideRanges[mid][1]:
+			lo = mid + 1
+		default:
+			return 2
+		}
+	}
+	return 1
+}
+
+// cell 布局的最小单位：一个转义序列或一个可见字符
+type cell struct {
+	text   string
+	width  int
+	escape bool // ANSI 转义序列，不占宽度
+	tab    bool // 制表符，宽度取决于所在列
+}
+
+// nextCell 从位置 i 读取下一个单元，返回单元和下一个位置
+// 转义序列整体作为一个单元，保证不会被截断；控制字符以 ^X 形式显示
+func nextCell(s string, i int) (cell, int) {
+	ch := s[i]
+	if ch == '\033' {
+		if end := escapeEnd(s, i); end > i {
+			return cell{text: s[i:end], escape: true}, end
+		}
+		return cell{text: "^[", width: 2}, i + 1
+	}
+	if ch == '\t' {
+		return cell{text: "\t", tab: true}, i + 1
+	}
+	if ch < 0x20 || ch == 0x7F {
+		return cell{text: "^" + string(ch^0x40), width: 2}, i + 1
+	}
+	if ch < utf8.RuneSelf {
+		return cell{text: s[i : i+1], width: 1}, i + 1
+	}
+	r, size := utf8.DecodeRuneInString(s[i:])
+	if r == utf8.RuneError && size == 1 {
+		// 非法的 UTF-8 字节按一列处理
+		return cell{text: s[i : i+1], width: 1}, i + 1
+	}
+	return cell{text: s[i : i+size], width: runeWidth(r)}, i + size
+}
+
+// escapeEnd 返回从 i 开始的 ANSI 转义序列的结束位置，不是合法序列时返回 i
+func escapeEnd(s string, i int) int {
+	if i+1 >= len(s) {
+		return i
+	}
+	if s[i+1] != '[' {
+		// ESC 加单个字符的序列
+		if s[i+1] >= 0x40 && s[i+1] <= 0x7E {
+			return i + 2
+		}
+		return i
+	}
+	// CSI 序列：ESC [ 参数... 终止字节(0x40-0x7E)
+	for j := i + 2; j < len(s); j++ {
+		if s[j] >= 0x40 && s[j] <= 0x7E {
+			return j + 1
+		}
+		if s[j] < 0x20 {
+			return i
+		}
+	}
+	return i
+}
+
+// updateSGR 根据新的转义序列更新当前生效的颜色状态
+// 遇到重置序列时清空，其他颜色序列累加
+func updateSGR(active, seq string) string {
+	if !strings.HasSuffix(seq, "m") || !strings.HasPrefix(seq, "\033[") {
+		return active
+	}
+	if seq == "\033[0m" || seq == "\033[m" {
+		return ""
+	}
+	return active + seq
+}
+
+// displayWidth 返回字符串的显示宽度（忽略转义序列，宽字符按 2 列计算）
+func displayWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		c, next := nextCell(s, i)
+		i = next
+		switch {
+		case c.escape:
+		case c.tab:
+			width += tabWidth - width%tabWidth
+		default:
+			width += c.width
+		}
+	}
+	return width
+}
+
+// wrapLine 按显示宽度将一行切分为多个屏幕行，最多返回 limit 行（limit <= 0 表示不限制）
+// 不会拆开多字节字符或转义序列；跨行的颜色会在行尾重置、在下一行开头恢复。
+// more 表示还有超出 limit 的内容未返回
+func wrapLine(s string, width, limit int) (rows []string, more bool) {
+	if width < 1 {
+		width = 1
+	}
+
+	var row strings.Builder
+	col := 0     // 当前屏幕行已使用的宽度
+	lineCol := 0 // 在整行中的列位置（用于制表符对齐）
+	active := ""
+
+	breakRow := func() {
+		if active != "" {
+			row.WriteString("\033[0m")
+		}
+		rows = append(rows, row.String())
+		row.Reset()
+		row.WriteString(active)
+		col = 0
+	}
+	put := func(text string, w int) {
+		if col+w > width && col > 0 {
+			if limit > 0 && len(rows)+1 >= limit {
+				more = true
+				return
+			}
+			breakRow()
+		}
+		row.WriteString(text)
+		col += w
+		lineCol += w
+	}
+
+	for i := 0; i < len(s) && !more; {
+		c, next := nextCell(s, i)
+		i = next
+		switch {
+		case c.escape:
+			row.WriteString(c.text)
+			active = updateSGR(active, c.text)
+		case c.tab:
+			// 制表符展开为空格，可以跨屏幕行
+			for n := tabWidth - lineCol%tabWidth; n > 0; n-- {
+				put(" ", 1)
+			}
+		default:
+			put(c.text, c.width)
+		}
+	}
+	if active != "" {
+		row.WriteString("\033[0m")
+	}
+	rows = append(rows, row.String())
+	return rows, more
+}
+
+// truncateWidth 将字符串截断到指定显示宽度，保留转义序列并在截断处重置颜色
+func truncateWidth(s string, width int) string {
+	rows, _ := wrapLine(s, width, 1)
+	return rows[0]
+}
+
+// sliceWidth 截取显示列 [from, from+width) 范围内的内容，用于不换行模式的水平滚动
+// 保留区间之前生效的颜色；被区间边界切开的宽字符以空格代替。
+// moreLeft/moreRight 表示区间左侧/右侧是否还有可见内容
+func sliceWidth(s string, from, width int) (out string, moreLeft, moreRight bool) {
+	var sb strings.Builder
+	active := ""
+	col := 0 // 在整行中的列位置
+	end := from + width
+	started := false // 是否已进入可见区间
+
+	put := func(text string, w int) {
+		if !started && w > 0 && col+w > from && col < end {
+			// 进入可见区间时恢复之前生效的颜色
+			sb.WriteString(active)
+			started = true
+		}
+		switch {
+		case w == 0:
+			if col >= from && col < end {
+				sb.WriteString(text)
+			}
+		case col+w <= from:
+			moreLeft = true
+		case col >= end:
+			moreRight = true
+		case col < from || col+w > end:
+			// 宽字符跨越边界，可见部分用空格填充
+			if col < from {
+				moreLeft = true
+			} else {
+				moreRight = true
+			}
+			for c := col; c < col+w; c++ {
+				if c >= from && c < end {
+					sb.WriteByte(' ')
+				}
+			}
+		default:
+			sb.WriteString(text)
+		}
+		col += w
+	}
+
+	for i := 0; i < len(s) && !moreRight; {
+		c, next := nextCell(s, i)
+		i = next
+		if !started && col >= from && col < end {
+			sb.WriteString(active)
+			started = true
+		}
+		switch {
+		case c.escape:
+			active = updateSGR(active, c.text)
+			if started {
+				sb.WriteString(c.text)
+			}
+		case c.tab:
+			for n := tabWidth - col%tabWidth; n > 0; n-- {
+				put(" ", 1)
+			}
+		default:
+			put(c.text, c.width)
+		}
+	}
+	if active != "" && started {
+		sb.WriteString("\033[0m")
+	}
+	return sb.String(), moreLeft, moreRight
+}
+
+// chopMarker 不换行模式下提示内容在屏幕外继续的标记
+const (
+	chopMarkerLeft  = "\033[7m<\033[0m"
+	chopMarkerRight = "\033[7m>\033[0m"
+)
+
+// chopRow 不换行模式下生成一行的可见部分，从第 offset 列开始，宽度为 width
+// 左右两侧还有内容时，在边缘一列显示标记
+func chopRow(line string, offset, width int) string {
+	start, avail := offset, width
+	if offset > 0 {
+		// 左侧保留一列给标记，内容对齐位置保持不变
+		start++
+		avail--
+	}
+
+	content, moreLeft, moreRight := sliceWidth(line, start, avail)
+	if moreRight {
+		content, moreLeft, _ = sliceWidth(line, start, avail-1)
+		content += chopMarkerRight
+	}
+	if offset > 0 {
+		if moreLeft {
+			content = chopMarkerLeft + content
+		} else {
+			content = " " + content
+		}
+	}
+	return content
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// editResult 行编辑器处理一次按键后的状态
+type editResult int
+
+const (
+	editContinue editResult = iota // 继续编辑
+	editSubmit                     // 按下 Enter，提交输入
+	editCancel                     // 按下 ESC 或删除了全部内容，取消输入
+)
+
+// lineEditor 底部命令行（: 命令和 / 搜索）的编辑器
+// 以字符（rune）为单位编辑，支持光标移动、按词删除和粘贴，中文等多字节字符不会被拆开
+type lineEditor struct {
+	prefix  string // 提示符，例如 ":" 或 "/"
+	buf     []rune
+	cursor  int  // 光标位置（buf 中的字符索引）
+	scroll  int  // 内容过长时第一个显示的字符索引
+	pasting bool // 处于括号粘贴模式中，换行按空格插入
+
+	history   []string // 该提示符的历史记录，从旧到新
+	histIndex int      // 当前浏览的历史位置，等于 len(history) 表示正在编辑新输入
+	draft     string   // 开始浏览历史前输入的内容
+}
+
+// newLineEditor 创建带提示符的空编辑器，history 为可用 ↑/↓ 浏览的历史记录（从旧到新）
+func newLineEditor(prefix string, history []string) *lineEditor {
+	return &lineEditor{prefix: prefix, history: history, histIndex: len(history)}
+}
+
+// historyPrev 切换到上一条（更旧的）历史记录
+func (e *lineEditor) historyPrev() {
+	if e.histIndex == 0 {
+		return
+	}
+	if e.histIndex == len(e.history) {
+		e.draft = e.text()
+	}
+	e.histIndex--
+	e.set(e.history[e.histIndex])
+}
+
+// historyNext 切换到下一条（更新的）历史记录，越过最新一条时恢复原来的输入
+func (e *lineEditor) historyNext() {
+	if e.histIndex == len(e.history) {
+		return
+	}
+	e.histIndex++
+	if e.histIndex == len(e.history) {
+		e.set(e.draft)
+	} else {
+		e.set(e.history[e.histIndex])
+	}
+}
+
+// text 返回当前输入的内容（不含提示符）
+func (e *lineEditor) text() string {
+	return string(e.buf)
+}
+
+// set 替换输入内容，光标移到末尾
+func (e *lineEditor) set(s string) {
+	e.buf = []rune(s)
+	e.cursor = len(e.buf)
+}
+
+// insert 在光标处插入字符
+func (e *lineEditor) insert(r rune) {
+	e.buf = append(e.buf, 0)
+	copy(e.buf[e.cursor+1:], e.buf[e.cursor:])
+	e.buf[e.cursor] = r
+	e.cursor++
+}
+
+// deleteRange 删除 [from, to) 范围内的字符，光标移到 from
+func (e *lineEditor) deleteRange(from, to int) {
+	e.buf = append(e.buf[:from], e.buf[to:]...)
+	e.cursor = from
+}
+
+// wordStart 返回光标前一个词的起始位置（先跳过空白，再跳过非空白）
+func (e *lineEditor) wordStart() int {
+	i := e.cursor
+	for i > 0 && unicode.IsSpace(e.buf[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(e.buf[i-1]) {
+		i--
+	}
+	return i
+}
+
+// handle 处理一个按键
+func (e *lineEditor) handle(key keyEvent) editResult {
+	switch key.code {
+	case keyPasteStart:
+		e.pasting = true
+		return editContinue
+	case keyPasteEnd:
+		e.pasting = false
+		return editContinue
+	case keyEscape:
+		return editCancel
+	case keyUp:
+		e.historyPrev()
+		return editContinue
+	case keyDown:
+		e.historyNext()
+		return editContinue
+	case keyLeft:
+		if e.cursor > 0 {
+			e.cursor--
+		}
+		return editContinue
+	case keyRight:
+		if e.cursor < len(e.buf) {
+			e.cursor++
+		}
+		return editContinue
+	case keyHome:
+		e.cursor = 0
+		return editContinue
+	case keyEnd:
+		e.cursor = len(e.buf)
+		return editContinue
+	case keyDelete:
+		if e.cursor < len(e.buf) {
+			e.deleteRange(e.cursor, e.cursor+1)
+		}
+		return editContinue
+	case keyRune:
+	default:
+		// 其他特殊按键在命令行中没有意义
+		return editContinue
+	}
+
+	r := key.r
+	if e.pasting {
+		// 粘贴的内容中换行和制表符按空格插入，不会提前提交
+		if r == '\r' || r == '\n' || r == '\t' {
+			r = ' '
+		}
+		if r >= 0x20 && r != 0x7F {
+			e.insert(r)
+		}
+		return editContinue
+	}
+
+	switch key.name() {
+	case "enter":
+		return editSubmit
+	case "ctrl+c", "ctrl+g":
+		return editCancel
+	case "backspace":
+		if len(e.buf) == 0 {
+			// 删除提示符即退出命令行（与 vim 一致）
+			return editCancel
+		}
+		if e.cursor > 0 {
+			e.deleteRange(e.cursor-1, e.cursor)
+		}
+	case "ctrl+a":
+		e.cursor = 0
+	case "ctrl+e":
+		e.cursor = len(e.buf)
+	case "ctrl+b":
+		if e.cursor > 0 {
+			e.cursor--
+		}
+	case "ctrl+f":
+		if e.cursor < len(e.buf) {
+			e.cursor++
+		}
+	case "ctrl+d":
+		if e.cursor < len(e.buf) {
+			e.deleteRange(e.cursor, e.cursor+1)
+		}
+	case "ctrl+p":
+		e.historyPrev()
+	case "ctrl+n":
+		e.historyNext()
+	case "ctrl+w":
+		e.deleteRange(e.wordStart(), e.cursor)
+	case "ctrl+u":
+		e.deleteRange(0, e.cursor)
+	case "ctrl+k":
+		e.buf = e.buf[:e.cursor]
+	default:
+		if r >= 0x20 && r != 0x7F {
+			e.insert(r)
+		}
+	}
+	return editContinue
+}
+
+// render 生成命令行的显示内容和光标所在的列（0 基）
+// 内容超出屏幕宽度时水平滚动，保证光标始终可见
+func (e *lineEditor) render(width int) (string, int) {
+	avail := width - displayWidth(e.prefix) - 1 // 末尾留一列给光标
+	if avail < 1 {
+		avail = 1
+	}
+
+	if e.cursor < e.scroll {
+		e.scroll = e.cursor
+	}
+	for e.scroll < e.cursor && runesWidth(e.buf[e.scroll:e.cursor]) > avail {
+		e.scroll++
+	}
+
+	var sb strings.Builder
+	sb.WriteString(e.prefix)
+	col := 0
+	cursorCol := 0
+	for i := e.scroll; i < len(e.buf); i++ {
+		if i == e.cursor {
+			cursorCol = col
+		}
+		w := runeWidth(e.buf[i])
+		if col+w > avail {
+			break
+		}
+		sb.WriteRune(e.buf[i])
+		col += w
+	}
+	if e.cursor == len(e.buf) {
+		cursorCol = col
+	}
+	return sb.String(), displayWidth(e.prefix) + cursorCol
+}
+
+// runesWidth 返回一组字符的显示宽度
+func runesWidth(rs []rune) int {
+	width := 0
+	for _, r := range rs {
+		width += runeWidth(r)
+	}
+	return width
+}
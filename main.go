@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -21,10 +22,32 @@ var (
 	helpFlag      bool
 	keepOneLine   bool
 	trimSpace     bool
-	lineNumColor  string // 行号颜色 (ANSI 其它色也一样)
-	searchHlColor string // 搜索高亮颜色
+	lineNumColor  string   // 行号颜色 (ANSI 其它色也一样)
+	searchHlColor string   // 搜索高亮颜色
+	jsonColor     bool     // 分页视图中为 JSON 行着色
+	jsonThemeName string   // JSON 配色方案
+	extractSpec   string   // 流模式下提取的 JSON 路径
+	escapeSpec    string   // 启用的转义符类别
+	quotedOnly    bool     // 只替换双引号字符串内部的转义符
+	chopLongLines bool     // 不换行模式（截断长行，左右滚动查看）
+	noAltScreen   bool     // 不使用备用屏幕，退出后保留最后一页内容
+	mouseFlag     bool     // 开启鼠标报告（滚轮滚动）
+	configFlag    string   // 配置文件路径
+	themeName     string   // 配色方案（内置的 dark、light 或配置文件中定义的方案）
+	colorMode     string   // 何时输出颜色：auto、always、never
+	searchCase    string   // 搜索的大小写模式：ignore、smart、sensitive
+	searchWord    bool     // 搜索和高亮只匹配整词
+	recordMode    string   // 多行记录的划分模式：auto、timestamp、indent、none
+	recordStart   string   // 记录起始行的正则表达式，指定后代替 recordMode
+	foldRecords   bool     // 启动时折叠所有多行记录
+	uniqMode      uniqFlag // 合并连续的重复行：mask、exact，为空表示不合并
+	patternsFlag  bool     // 输出日志模板列表
+	jsonOutput    bool     // 子命令以 JSON 格式输出
 )
 
+// extractPaths 由 --extract 解析得到的路径列表，为空表示不提取
+var extractPaths []jsonPath
+
 // 命令行参数描述常量
 const (
 	descFilePath      = "日志文件路径"
@@ -35,6 +58,26 @@ const (
 	descHelp          = "显示帮助信息"
 	descLineNumColor  = "行号颜色"
 	descSearchHlColor = "搜索高亮颜色"
+	descJSONColor     = "在分页视图中为 JSON 行着色"
+	descJSONTheme     = "JSON 配色方案"
+	descExtract       = "提取每行 JSON 中指定路径的值（逗号分隔，如 .user_id,.latency）"
+	descEscapes       = "启用的转义符类别(basic, json, unicode, hex, octal, all)"
+	descQuotedOnly    = "只替换双引号字符串内部的转义符"
+	descChopLines     = "不换行显示长行，使用左右方向键水平滚动"
+	descNoAltScreen   = "不使用终端备用屏幕，退出后保留最后一页内容"
+	descMouse         = "开启鼠标支持（滚轮滚动），开启后需按住 Shift 选择文本"
+	descConfig        = "配置文件路径（默认: ~/.config/loglens/config.toml）"
+	descTheme         = "配色方案（dark、light 或配置文件中定义的方案）"
+	descColor         = "何时输出颜色（auto、always、never）"
+	descSearchCase    = "搜索的大小写模式（ignore、smart、sensitive）"
+	descSearchWord    = "搜索只匹配整词（如 id=12 不匹配 id=123）"
+	descRecords       = "多行记录的划分方式（auto、timestamp、indent、none）"
+	descRecordStart   = "记录起始行的正则表达式，不匹配的行属于上一条记录"
+	descFold          = "启动时折叠所有多行记录（如堆栈），交互模式中按 z/Z 展开"
+	descUniq          = "合并连续的重复行并显示次数（默认屏蔽数字、UUID、时间戳后比较，--uniq=exact 只合并完全相同的行）"
+	descPatterns      = "将日志归类为模板（可变部分显示为 <*>），按出现次数输出模板和示例行"
+	descJSONOutput    = "stats、histogram 子命令以 JSON 格式输出（用于脚本）"
+	descHighlight     = "固定高亮的关键词，可重复指定（\"<模式>\" 或 \"@<颜色> <模式>\"）"
 )
 
 // 预设颜色映射表（前景色）
@@ -58,6 +101,9 @@ var searchHlColorMap = map[string]string{
 	"cyan":    "46;30", // 青色背景 + 黑色文字
 }
 
+// wheelScrollLines 鼠标滚轮每次滚动的行数
+const wheelScrollLines = 3
+
 // 错误消息常量
 const (
 	errMsgOpenFile = "无法打开文件 %s: %v"
@@ -72,28 +118,6 @@ const (
 	maxScanTokenSize = 5 * 1024 * 1024 // 5MB
 )
 
-// 字符串转义替换器（用于 unescapeString 函数）
-var (
-	// escapeReplacerKeepOneLine 保持单行模式的转义符替换器（\n 替换为空格）
-	escapeReplacerKeepOneLine = strings.NewReplacer(
-		"\\t", "\t",
-		"\\r", "",
-		"\\n", " ", // 替换为空格
-		"\\\\", "\\",
-		"\\\"", "\"",
-		"\\'", "'",
-	)
-	// escapeReplacerNormal 正常模式的转义符替换器（\n 替换为换行符）
-	escapeReplacerNormal = strings.NewReplacer(
-		"\\n", "\n",
-		"\\t", "\t",
-		"\\r", "\r",
-		"\\\\", "\\",
-		"\\\"", "\"",
-		"\\'", "'",
-	)
-)
-
 func init() {
 	flag.BoolVar(&unescapeFlag, "u", false, descUnescape)
 	flag.BoolVar(&unescapeFlag, "unescape", false, descUnescape)
@@ -101,8 +125,30 @@ func init() {
 	flag.BoolVar(&keepOneLine, "keep-one-line", false, descKeepOneLine)
 	flag.BoolVar(&trimSpace, "t", false, descTrimSpace)
 	flag.BoolVar(&trimSpace, "trim", false, descTrimSpace)
-	flag.StringVar(&lineNumColor, "line-color", "cyan", descLineNumColor)
-	flag.StringVar(&searchHlColor, "search-color", "yellow", descSearchHlColor)
+	flag.BoolVar(&chopLongLines, "S", false, descChopLines)
+	flag.BoolVar(&chopLongLines, "chop-long-lines", false, descChopLines)
+	flag.BoolVar(&noAltScreen, "X", false, descNoAltScreen)
+	flag.BoolVar(&noAltScreen, "no-alt-screen", false, descNoAltScreen)
+	flag.BoolVar(&mouseFlag, "mouse", false, descMouse)
+	flag.StringVar(&lineNumColor, "line-color", "", descLineNumColor)
+	flag.StringVar(&searchHlColor, "search-color", "", descSearchHlColor)
+	flag.BoolVar(&jsonColor, "json-color", false, descJSONColor)
+	flag.StringVar(&jsonThemeName, "json-theme", "", descJSONTheme)
+	flag.StringVar(&extractSpec, "extract", "", descExtract)
+	flag.StringVar(&escapeSpec, "escapes", defaultEscapeSpec, descEscapes)
+	flag.BoolVar(&quotedOnly, "unescape-quoted", false, descQuotedOnly)
+	flag.StringVar(&configFlag, "config", "", descConfig)
+	flag.StringVar(&themeName, "theme", "", descTheme)
+	flag.StringVar(&colorMode, "color", "auto", descColor)
+	flag.Var(&highlightSpecs, "highlight", descHighlight)
+	flag.StringVar(&searchCase, "search-case", caseIgnore, descSearchCase)
+	flag.BoolVar(&searchWord, "search-word", false, descSearchWord)
+	flag.StringVar(&recordMode, "records", recordsAuto, descRecords)
+	flag.StringVar(&recordStart, "record-start", "", descRecordStart)
+	flag.BoolVar(&foldRecords, "fold", false, descFold)
+	flag.Var(&uniqMode, "uniq", descUniq)
+	flag.BoolVar(&patternsFlag, "patterns", false, descPatterns)
+	flag.BoolVar(&jsonOutput, "json", false, descJSONOutput)
 	flag.BoolVar(&helpFlag, "h", false, descHelp)
 	flag.BoolVar(&helpFlag, "help", false, descHelp)
 }
@@ -119,41 +165,168 @@ func exitWithError(format string, args ...interface{}) {
 }
 
 // convertLineNumColor 转换行号颜色名称为ANSI代码
-func convertLineNumColor(colorName string) string {
+func convertLineNumColor(colorName string) (string, error) {
 	// 先检查是否是预设颜色名称
 	if ansiCode, ok := lineNumColorMap[colorName]; ok {
-		return ansiCode
+		return ansiCode, nil
 	}
-	// 如果不是预设名称，不学会是 ANSI 代码，直接返回
-	return colorName
+	// 其他样式描述：#rrggbb、color208、bold red 或原始 ANSI 代码
+	return parseStyle(colorName)
 }
 
 // convertSearchHlColor 转换搜索高亮颜色名称为ANSI代码
-func convertSearchHlColor(colorName string) string {
-	// 先检查是否是预设颜色名称
+func convertSearchHlColor(colorName string) (string, error) {
+	// 先检查是否是预设颜色名称（背景色）
 	if ansiCode, ok := searchHlColorMap[colorName]; ok {
-		return ansiCode
+		return ansiCode, nil
 	}
-	// 如果不是预设名称，学会是 ANSI 代码，直接返回
-	return colorName
+	// 其他样式描述，例如 "black on #ffd75f"
+	return parseStyle(colorName)
+}
+
+// setupTheme 确定当前使用的配色：先选择配色方案，再应用 --line-color、
+// --search-color、--json-theme 的单项覆盖；禁用颜色时使用不含颜色的方案
+func setupTheme() error {
+	useColor, err := colorEnabled(colorMode)
+	if err != nil {
+		return err
+	}
+	colorOutput = useColor
+	if !useColor {
+		activeTheme = noColorTheme
+		return nil
+	}
+
+	t, err := loadTheme(themeName)
+	if err != nil {
+		return err
+	}
+	if lineNumColor != "" {
+		if t.Gutter, err = convertLineNumColor(lineNumColor); err != nil {
+			return fmt.Errorf("--line-color: %v", err)
+		}
+	}
+	if searchHlColor != "" {
+		if t.Search, err = convertSearchHlColor(searchHlColor); err != nil {
+			return fmt.Errorf("--search-color: %v", err)
+		}
+	}
+	if jsonThemeName != "" {
+		if t.JSON, err = convertJSONTheme(jsonThemeName, t.JSON); err != nil {
+			return err
+		}
+	}
+	activeTheme = t
+	return nil
+}
+
+// subcommands 子命令，写在文件路径之前（如 lg stats app.log），子命令之后仍可以使用选项
+var subcommands = map[string]bool{
+	"stats":     true,
+	"histogram": true,
+}
+
+// isSubcommand 判断位置参数中的第一个是否为子命令
+// 当前目录下有同名的文件且之后没有其他参数时（lg stats）打开该文件，
+// 统计该文件可以写成 lg stats ./stats
+func isSubcommand(args []string) bool {
+	if len(args) == 0 || !subcommands[args[0]] {
+		return false
+	}
+	if len(args) > 1 {
+		return true
+	}
+	_, err := os.Stat(args[0])
+	return err != nil
 }
 
 func main() {
 	flag.Parse()
 
-	// 转换颜色名称为ANSI代码
-	lineNumColor = convertLineNumColor(lineNumColor)
-	searchHlColor = convertSearchHlColor(searchHlColor)
+	command := ""
+	if isSubcommand(flag.Args()) {
+		command = flag.Arg(0)
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
 
-	// 显示帮助信息
+	// 获取文件路径：优先使用 -f 参数，其次使用位置参数
+	if filePath == "" && flag.NArg() > 0 {
+		filePath = flag.Arg(0)
+	}
+
+	// 显示帮助信息（在读取配置文件之前，配置文件有误时也能查看用法）
 	if helpFlag {
 		showHelp()
 		return
 	}
 
-	// 获取文件路径：优先使用 -f 参数，其次使用位置参数
-	if filePath == "" && flag.NArg() > 0 {
-		filePath = flag.Arg(0)
+	// 读取配置文件，命令行参数优先于配置文件
+	if err := loadConfig(configFlag, filePath); err != nil {
+		exitWithError(errMsgGeneric, err)
+	}
+
+	// 确定配色（颜色名称转换为 ANSI 代码）
+	if err := setupTheme(); err != nil {
+		exitWithError(errMsgGeneric, err)
+	}
+	if err := parseCaseMode(searchCase); err != nil {
+		exitWithError(errMsgGeneric, err)
+	}
+	if _, err := newRecordSplitter(); err != nil {
+		exitWithError(errMsgGeneric, err)
+	}
+	for _, spec := range highlightSpecs {
+		if err := addHighlight(spec); err != nil {
+			exitWithError(errMsgGeneric, err)
+		}
+	}
+
+	escapes, err := parseEscapeSet(escapeSpec)
+	if err != nil {
+		exitWithError(errMsgGeneric, err)
+	}
+	escapeConfig = escapes
+
+	if extractSpec != "" {
+		paths, err := parseJSONPaths(extractSpec)
+		if err != nil {
+			exitWithError(errMsgGeneric, err)
+		}
+		extractPaths = paths
+	}
+
+	// 统计报告：读取整个文件或标准输入后输出
+	if command == "stats" {
+		reader, name := openInput()
+		defer reader.Close()
+		if err := printStats(name, reader); err != nil {
+			exitWithError(errMsgReadFile, err)
+		}
+		return
+	}
+
+	// 时间分布柱状图：宽度跟随终端，输出被重定向时为 80 列
+	if command == "histogram" {
+		reader, _ := openInput()
+		defer reader.Close()
+		width, _, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width = 80
+		}
+		if err := printHistogram(reader, width); err != nil {
+			exitWithError(errMsgReadFile, err)
+		}
+		return
+	}
+
+	// 模板列表：读取整个文件或标准输入后输出
+	if patternsFlag {
+		reader, _ := openInput()
+		defer reader.Close()
+		if err := printPatterns(reader); err != nil {
+			exitWithError(errMsgReadFile, err)
+		}
+		return
 	}
 
 	// 如果没有指定文件,从标准输入读取
@@ -173,8 +346,8 @@ func main() {
 
 	// 检查是否是管道输出或非交互式终端
 	fileInfo, _ := os.Stdout.Stat()
-	if (fileInfo.Mode() & os.ModeCharDevice) == 0 {
-		// 输出被重定向,使用非交互模式
+	if (fileInfo.Mode()&os.ModeCharDevice) == 0 || len(extractPaths) > 0 {
+		// 输出被重定向或需要提取字段,使用非交互模式
 		file, err := os.Open(filePath)
 		if err != nil {
 			exitWithError(errMsgOpenFile, filePath, err)
@@ -192,38 +365,107 @@ func main() {
 	}
 }
 
-// processStream 处理输入流并输出
-func processStream(reader io.Reader) error {
+// openInput 打开要读取的文件，没有指定文件时使用标准输入，同时返回输入的名称
+func openInput() (io.ReadCloser, string) {
+	if filePath == "" {
+		return os.Stdin, "标准输入"
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		exitWithError(errMsgOpenFile, filePath, err)
+	}
+	return file, filePath
+}
+
+// newLogScanner 创建按行读取日志的 Scanner，使用更大的缓冲区以处理超长行
+func newLogScanner(reader io.Reader) *bufio.Scanner {
 	scanner := bufio.NewScanner(reader)
-	// 设置更大的缓冲区以处理超长行
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, maxScanTokenSize)
+	return scanner
+}
+
+// processStream 处理输入流并输出
+func processStream(reader io.Reader) error {
+	scanner := newLogScanner(reader)
+	splitter, err := newRecordSplitter()
+	if err != nil {
+		return err
+	}
+	// 去重模式下暂存一组连续的重复行，遇到不同的行时输出第一行和重复次数
+	var pending struct {
+		lineNum int
+		mark    string
+		line    string
+		last    string
+		key     string
+		count   int
+	}
+	flush := func() {
+		if pending.count == 0 {
+			return
+		}
+		// 使用配置的颜色显示行号、日志等级和固定高亮，续行显示在所属记录下方
+		// 禁用颜色时不输出固定高亮：下划线只用于分页器，重定向的输出中不应有转义序列
+		spans := levelSpans(pending.line)
+		if colorOutput {
+			spans = append(spans, highlightSpans(pending.line)...)
+		}
+		summary := ""
+		if pending.count > 1 {
+			summary = paint(activeTheme.Muted, dupSummary(pending.count, pending.line, pending.last))
+		}
+		fmt.Printf("%s%s%s\n", linePrefix(pending.lineNum-1, pending.mark), renderSpans(pending.line, spans), summary)
+		pending.count = 0
+	}
+
 	lineNum := 1
 	for scanner.Scan() {
 		line := scanner.Text()
+		start := splitter.isStart(line)
 		if trimSpace {
 			line = strings.TrimSpace(line)
 		}
+		// 提取模式：只输出指定路径的值，跳过非 JSON 行
+		if len(extractPaths) > 0 {
+			if values, ok := extractValues(jsonSourceLine(line), extractPaths); ok {
+				fmt.Println(values)
+			}
+			lineNum++
+			continue
+		}
 		if unescapeFlag {
 			line = unescapeString(line)
 		}
-		// 使用配置的颜色显示行号
-		fmt.Printf("\033[%sm%6d\033[0m  %s\n", lineNumColor, lineNum, line)
+		key := dedupeKey(line, string(uniqMode))
+		if uniqMode != uniqOff && pending.count > 0 && key == pending.key {
+			pending.count++
+			pending.last = line
+			lineNum++
+			continue
+		}
+		flush()
+
+		mark := markNone
+		if !start {
+			mark = markContinuation
+		}
+		pending.lineNum, pending.mark, pending.line, pending.last, pending.key, pending.count = lineNum, mark, line, line, key, 1
+		if uniqMode == uniqOff {
+			// 不去重时立即输出，管道中的实时日志不会被延迟
+			flush()
+		}
 
 		lineNum++
 	}
+	flush()
 	return scanner.Err()
 }
 
 // unescapeString 替换字符串中的转义符
+// keepOneLine 时 \n 替换为空格，否则替换为真正的换行符
 func unescapeString(s string) string {
-	if keepOneLine {
-		// 保持在一行：将 \n 替换为空格
-		return escapeReplacerKeepOneLine.Replace(s)
-	} else {
-		// 正常替换：\n 替换为真正的换行符
-		return escapeReplacerNormal.Replace(s)
-	}
+	return decodeEscapes(s, escapeConfig, keepOneLine, quotedOnly)
 }
 
 // isJSONLine 检查一行是否是 JSON 格式
@@ -233,100 +475,124 @@ func isJSONLine(line string) bool {
 }
 
 // formatJSONLine 格式化 JSON 行为美化的多行显示
+// 保留原始键顺序和数字原文；字符串值中内嵌的 JSON（包括 base64 编码的 JSON）会被展开并标记
 func formatJSONLine(line string) (string, error) {
-	var jsonObj interface{}
-	if err := json.Unmarshal([]byte(line), &jsonObj); err != nil {
-		return line, err
-	}
-
-	formatted, err := json.MarshalIndent(jsonObj, "", "  ")
+	node, err := parseJSON(line)
 	if err != nil {
 		return line, err
 	}
 
-	return string(formatted), nil
+	return prettyJSON(node), nil
 }
 
-// showFormattedJSON 在独立页面显示格式化的 JSON
-func showFormattedJSON(filePath string, lineIndex []int64, lineNum int) error {
-	// 读取指定行的内容
-	file, err := os.Open(filePath)
+// readTrimmedLine 读取指定行（0 基索引）的内容，并按 -t 参数修剪空白
+func readTrimmedLine(src *lineReader, lineNum int) (string, error) {
+	line, err := src.line(lineNum)
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer file.Close()
-
-	// 定位到指定行
-	if lineNum >= len(lineIndex) {
-		return fmt.Errorf("行号超出范围")
+	if trimSpace {
+		line = strings.TrimSpace(line)
 	}
+	return line, nil
+}
 
-	_, err = file.Seek(lineIndex[lineNum], 0)
-	if err != nil {
-		return err
+// jsonSourceLine 返回用于 JSON 解析的行内容
+// 原始行本身是合法 JSON 时直接使用，内嵌的转义 JSON 由格式化器展开；
+// 否则才尝试替换转义符，避免 -u 破坏外层 JSON 结构
+func jsonSourceLine(line string) string {
+	if unescapeFlag && !isJSONLine(line) {
+		return unescapeString(line)
 	}
+	return line
+}
+
+// showMessagePage 清屏显示标题和内容，等待用户按任意键返回
+func showMessagePage(title, content string) {
+	fmt.Print("\033[2J\033[H")
+	fmt.Printf("%s\r\n\r\n", title)
 
-	scanner := bufio.NewScanner(file)
-	// 设置更大的缓冲区以处理超长行
-	scanBuf := make([]byte, 0, 64*1024)
-	scanner.Buffer(scanBuf, maxScanTokenSize)
-	if !scanner.Scan() {
-		return fmt.Errorf("无法读取行内容")
+	// 按行输出，确保使用 \r\n
+	for _, line := range strings.Split(content, "\n") {
+		fmt.Print(line + "\r\n")
 	}
 
-	rawLine := scanner.Text()
-	if trimSpace {
-		rawLine = strings.TrimSpace(rawLine)
+	fmt.Print("\r\n" + paint(activeTheme.Muted, "按任意键返回..."))
+
+	// 等待用户按键
+	waitForKey()
+}
+
+// readJSONSource 读取用于 JSON 解析的内容和描述其位置的标签（如 "第 5 行"、"第 5-9 行"）
+// 所在记录跨多行且整体是 JSON（如格式化输出的 JSON）时使用整条记录，否则只使用这一行
+func readJSONSource(src *lineReader, records recordIndex, lineNum int) (string, string, error) {
+	if start, end := records.bounds(lineNum); end-start > 1 {
+		text, start, end, err := recordText(src, records, lineNum)
+		if err != nil {
+			return "", "", err
+		}
+		if text = jsonSourceLine(text); isJSONLine(text) {
+			return text, fmt.Sprintf("第 %d-%d 行", start+1, end), nil
+		}
 	}
-	if unescapeFlag {
-		rawLine = unescapeString(rawLine)
+	rawLine, err := readTrimmedLine(src, lineNum)
+	if err != nil {
+		return "", "", err
+	}
+	return jsonSourceLine(rawLine), fmt.Sprintf("第 %d 行", lineNum+1), nil
+}
+
+// showFormattedJSON 在独立页面显示格式化的 JSON
+func showFormattedJSON(src *lineReader, records recordIndex, lineNum int) error {
+	rawLine, label, err := readJSONSource(src, records, lineNum)
+	if err != nil {
+		return err
 	}
 
 	// 检查是否是 JSON
 	if !isJSONLine(rawLine) {
-		// 清屏并显示错误信息
-		fmt.Print("\033[2J\033[H")
-		fmt.Printf("第 %d 行不是有效的 JSON 格式\r\n\r\n", lineNum+1)
-		fmt.Printf("原始内容：\r\n%s\r\n\r\n", rawLine)
-		fmt.Print("按任意键返回...")
-
-		// 等待用户按键
-		buf := make([]byte, 1)
-		os.Stdin.Read(buf)
+		showMessagePage(label+"不是有效的 JSON 格式", "原始内容：\n"+rawLine)
 		return nil
 	}
 
 	// 格式化 JSON
 	formatted, err := formatJSONLine(rawLine)
 	if err != nil {
-		// 清屏并显示错误信息
-		fmt.Print("\033[2J\033[H")
-		fmt.Printf("JSON 格式化失败: %v\r\n\r\n", err)
-		fmt.Printf("原始内容：\r\n%s\r\n\r\n", rawLine)
-		fmt.Print("按任意键返回...")
-
-		// 等待用户按键
-		buf := make([]byte, 1)
-		os.Stdin.Read(buf)
+		showMessagePage(fmt.Sprintf("JSON 格式化失败: %v", err), "原始内容：\n"+rawLine)
 		return nil
 	}
 
-	// 清屏并显示格式化的 JSON
-	fmt.Print("\033[2J\033[H")
-	fmt.Printf("\033[32m=== 第 %d 行 JSON 格式化 ===\033[0m\r\n\r\n", lineNum+1)
+	// 显示着色后的格式化 JSON
+	showMessagePage(paint(activeTheme.Title, fmt.Sprintf("=== %s JSON 格式化 ===", label)), colorizeJSON(formatted))
+	return nil
+}
 
-	// 按行输出格式化的 JSON，确保使用 \r\n
-	lines := strings.Split(formatted, "\n")
-	for _, line := range lines {
-		fmt.Print(line + "\r\n")
+// showPathValue 在独立页面显示指定行中某个 JSON 路径的值（:p 命令）
+func showPathValue(src *lineReader, records recordIndex, lineNum int, expr string) error {
+	path, err := parseJSONPath(strings.TrimSpace(expr))
+	if err != nil {
+		showMessagePage(fmt.Sprintf("路径错误: %v", err), "")
+		return nil
 	}
 
-	fmt.Print("\r\n\033[90m按任意键返回...\033[0m")
+	rawLine, label, err := readJSONSource(src, records, lineNum)
+	if err != nil {
+		return err
+	}
 
-	// 等待用户按键
-	buf := make([]byte, 1)
-	os.Stdin.Read(buf)
+	node, err := parseJSON(rawLine)
+	if err != nil {
+		showMessagePage(label+"不是有效的 JSON 格式", "原始内容：\n"+rawLine)
+		return nil
+	}
 
+	title := paint(activeTheme.Title, fmt.Sprintf("=== %s %s ===", label, path.expr))
+	value, ok := path.lookup(node)
+	if !ok {
+		showMessagePage(title, paint(activeTheme.Muted, "(路径不存在)"))
+		return nil
+	}
+	showMessagePage(title, colorizeJSON(prettyJSON(value)))
 	return nil
 }
 
@@ -380,48 +646,232 @@ func interactiveMode(filePath string) error {
 		viewHeight = 3 // 最少显示3行，即使窗口很小
 	}
 
-	// 保存原始终端状态
-	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	// 进入原始模式和备用屏幕，保存原始终端状态
+	session, err := startTerminalSession(!noAltScreen, mouseFlag)
 	if err != nil {
-		return fmt.Errorf("无法进入原始终端模式: %v", err)
+		return err
 	}
-	defer term.Restore(int(os.Stdin.Fd()), oldState)
+	defer session.restore()
+	// 发生 panic 时也要恢复终端，否则终端会停留在原始模式
+	defer func() {
+		if r := recover(); r != nil {
+			session.restore()
+			panic(r)
+		}
+	}()
+
+	// 所有按键和信号事件都通过 keyInput 传给主循环
+	in := newTerminalInput()
+	keyInput = in
+	defer func() {
+		keyInput = nil
+	}()
 
 	// 监听窗口大小变化信号
 	sigChan := make(chan os.Signal, 1)
 	setupSignalHandler(sigChan)
 	defer signal.Stop(sigChan)
 
-	// 用于标记是否需要重新显示
-	needRedisplay := false
-
 	// 在后台监听窗口大小变化
 	go func() {
 		for range sigChan {
-			needRedisplay = true
+			in.post(eventResize)
+		}
+	}()
+
+	// 收到终止信号时恢复终端后退出
+	exitChan := make(chan os.Signal, 1)
+	setupExitSignalHandler(exitChan)
+	defer signal.Stop(exitChan)
+	go func() {
+		if _, ok := <-exitChan; ok {
+			session.restore()
+			os.Exit(1)
 		}
 	}()
 
-	currentLine := 0
-	lastDisplayedLine := 0   // 记录上次显示的最后一行
-	searchPattern := ""      // 搜索模式
-	searchMatches := []int{} // 搜索结果（匹配的行号）
-	currentMatchIndex := -1  // 当前匹配的索引
+	// 挂起（SIGTSTP）前恢复终端，恢复运行（SIGCONT）后重新进入并重绘
+	suspendChan := make(chan os.Signal, 1)
+	setupSuspendSignalHandler(suspendChan)
+	defer signal.Stop(suspendChan)
+	go func() {
+		for sig := range suspendChan {
+			if isSuspendSignal(sig) {
+				session.restore()
+				suspendProcess(suspendChan)
+				session.enter()
+			} else {
+				session.reapply()
+			}
+			in.post(eventResume)
+		}
+	}()
 
-	// 显示第一页
-	lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
+	// 划分多行记录（堆栈等续行归入上方的记录）
+	records, err := buildRecordIndex(filePath, totalLines)
 	if err != nil {
 		return err
 	}
-	lastDisplayedLine = lastLine
+	view := &pageView{records: records}
+	if foldRecords {
+		view.toggleFoldAll()
+	}
+	uniqOn := uniqMode != uniqOff // 是否合并重复行，没有重复行时 view.dupes 为空，不能用来判断
+	if uniqOn {
+		if view.dupes, err = findDuplicates(filePath, totalLines, string(uniqMode)); err != nil {
+			return err
+		}
+		view.rebuild()
+	}
+
+	currentLine := 0           // 第一个显示的可见行（view 中的下标）
+	lastDisplayedLine := 0     // 记录上次显示的最后一个可见行
+	search := newSearchState() // 搜索模式和匹配位置
+	hOffset := 0               // 不换行模式下的水平滚动列数
+	maxHOffset := 0            // 当前页允许的最大水平滚动列数
+
+	// 按需读取行内容，分页期间保持文件打开
+	src, err := newLineReader(filePath, lineIndex, totalLines)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// 输出缓冲：布局计算与终端输出分离，只重绘变化的行
+	scr := newScreen(viewHeight)
+
+	// redraw 计算当前页的布局并绘制
+	redraw := func() error {
+		frame, err := layoutPage(src, view, currentLine, viewHeight, width, search, hOffset)
+		if err != nil {
+			return err
+		}
+		lastDisplayedLine = frame.lastLine
+		maxHOffset = frame.maxHOffset
+		scr.draw(frame.rows, statusLine(width, filepath.Base(filePath), view.filter, currentLine, lastDisplayedLine, view.len(),
+			search.pattern, search.index, len(search.matches)))
+		return nil
+	}
+
+	// gotoMatch 跳转到匹配所在的行；不换行模式下同时水平滚动到匹配位置
+	// 匹配位于多行记录中时，如果记录首行与匹配相距不远，从记录首行开始显示；
+	// 匹配在合并的重复行中时跳转到该组显示的那一行
+	gotoMatch := func(m searchMatch) {
+		if head, ok := view.groupHead(m.line); ok && head != m.line {
+			currentLine = view.indexOf(head)
+			return
+		}
+		top := m.line
+		if start, _ := records.bounds(m.line); m.line-start < viewHeight/2 {
+			top = start
+		}
+		// 匹配在折叠的记录中时展开该记录
+		if m.line != top || !records.isStart(m.line) {
+			view.unfold(m.line)
+		}
+		currentLine = view.indexOf(top)
+		if chopLongLines {
+			hOffset = revealColumn(src, m, hOffset, width-8)
+		}
+	}
+
+	// runSearch 按当前的搜索模式重新搜索（只保留过滤结果中的匹配），
+	// 并跳转到 fromLine 行及之后的第一个匹配
+	// 折叠的记录和合并的重复行中的匹配也保留，去重模式下合并的行内容可能不同（如不同的 id）
+	runSearch := func(fromLine int) {
+		search.matches = search.matches[:0]
+		for _, m := range searchInFile(filePath, totalLines, search.query) {
+			if view.inFilter(m.line) {
+				search.matches = append(search.matches, m)
+			}
+		}
+		search.index = -1
+		for i, m := range search.matches {
+			if m.line >= fromLine {
+				search.index = i
+				break
+			}
+		}
+		if search.index < 0 && len(search.matches) > 0 {
+			search.index = 0
+		}
+		if m, ok := search.current(); ok {
+			gotoMatch(m)
+		}
+	}
+
+	// applyFilter 设置过滤结果（filter 为空时取消过滤），停在原来的行或其后第一个可见行，
+	// 并在过滤结果中重新搜索
+	applyFilter := func(filter string, lines []int) {
+		top := view.line(currentLine)
+		view.setFilter(filter, lines)
+		currentLine = view.indexOf(top)
+		hOffset = 0
+		if search.pattern != "" {
+			runSearch(view.line(currentLine))
+		}
+	}
+
+	// openHistogram 显示当前过滤结果的时间分布柱状图，选中时间后跳转到该时间的第一条记录
+	openHistogram := func() error {
+		points, err := timePointsInFile(filePath, records, view.inFilter)
+		if err != nil {
+			return err
+		}
+		if len(points) == 0 {
+			redraw()
+			scr.prompt(paint(activeTheme.Error, "没有带时间戳的记录"), 0)
+			return nil
+		}
+		h := newHistogram(points, width-histogramGutter-1)
+		chosen := showHistogram(h, h.bucketOf(points, view.line(currentLine)), width, height)
+		scr.invalidate()
+		// 选中的时间没有记录时跳到其后第一条记录
+		for ; chosen >= 0 && chosen < len(h.buckets); chosen++ {
+			if b := h.buckets[chosen]; b.first >= 0 {
+				view.unfold(b.first)
+				currentLine = view.indexOf(b.first)
+				hOffset = 0
+				break
+			}
+		}
+		if err := redraw(); err != nil {
+			return err
+		}
+		if chosen >= 0 && chosen < len(h.buckets) {
+			scr.prompt("跳转到 "+formatTime(h.buckets[chosen].start), 0)
+		}
+		return nil
+	}
+
+	// 显示第一页
+	if err := redraw(); err != nil {
+		return err
+	}
+
+	// 搜索和命令历史，跨会话保存
+	history := loadPromptHistory(historyPath())
 
 	// 主循环
-	buf := make([]byte, 1)
-	commandBuf := []byte{}
+	var editor *lineEditor // 命令行编辑器，不在命令模式时为 nil
 	for {
-		// 检查是否需要因为窗口大小变化而重新显示
-		if needRedisplay {
-			needRedisplay = false
+		ev, err := in.next()
+		if err != nil {
+			break
+		}
+
+		// 挂起后恢复运行，终端内容已不可信，全部重画
+		if ev.kind == eventResume {
+			scr.invalidate()
+			redraw()
+			if editor != nil {
+				scr.prompt(editor.render(width))
+			}
+			continue
+		}
+
+		// 窗口大小变化，重新显示
+		if ev.kind == eventResize {
 			// 重新获取终端大小
 			newWidth, newHeight, err := term.GetSize(int(os.Stdout.Fd()))
 			if err == nil {
@@ -433,154 +883,215 @@ func interactiveMode(filePath string) error {
 				}
 			}
 			// 重新显示当前页
-			lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-			if err == nil {
-				lastDisplayedLine = lastLine
+			scr.resize(viewHeight)
+			redraw()
+			if editor != nil {
+				scr.prompt(editor.render(width))
 			}
 			continue
 		}
 
-		n, err := os.Stdin.Read(buf)
-		if err != nil || n == 0 {
-			break
-		}
-
-		ch := buf[0]
+		key := ev.key
 
 		// 处理命令模式
-		if len(commandBuf) > 0 {
-			// 已经在命令模式中，处理命令输入
-			if ch == '\r' || ch == '\n' {
-				// 执行命令
-				cmdType := commandBuf[0]
-				cmd := string(commandBuf[1:])
-
-				if cmdType == ':' {
-					// 检查是否是格式化命令 :f<行号>
-					if strings.HasPrefix(cmd, "f") {
-						// 格式化指定行的 JSON
-						lineNumStr := strings.TrimPrefix(cmd, "f")
-						if lineNumStr == "" {
-							// 如果没有指定行号，使用当前行
-							err := showFormattedJSON(filePath, lineIndex, currentLine)
+		if editor != nil {
+			// 已经在命令模式中，交给行编辑器处理
+			switch editor.handle(key) {
+			case editContinue:
+				scr.prompt(editor.render(width))
+				continue
+			case editCancel: // ESC - 取消命令
+				editor = nil
+				if err := redraw(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// 执行命令
+			cmdType := editor.prefix
+			cmd := editor.text()
+			editor = nil
+			history.add(cmdType, cmd)
+			if cmdType == ":" {
+				// 检查是否是路径提取命令 :p <路径>、高亮命令 :hl 或格式化命令 :f<行号>
+				if cmd == "patterns" {
+					// 模板列表，选中模板后只显示属于它的记录
+					patterns, total, err := minePatternsInFile(filePath, records)
+					if err != nil {
+						return err
+					}
+					chosen := showPatternMenu(patterns, total, width, height)
+					scr.invalidate()
+					if chosen != nil {
+						applyFilter("模板 "+chosen.template(), recordLines(records, chosen.lines))
+					}
+				} else if arg, ok := strings.CutPrefix(cmd, "top"); ok && (arg == "" || arg[0] == ' ') {
+					// 字段各个值的排行（只统计当前过滤结果），选中的值追加为过滤条件
+					field, err := parseTopField(arg)
+					if err != nil {
+						redraw()
+						scr.prompt(paint(activeTheme.Error, truncateWidth(err.Error(), width-1)), 0)
+						continue
+					}
+					breakdown, err := topFieldValues(filePath, records, field, view.inFilter)
+					if err != nil {
+						return err
+					}
+					if len(breakdown.values) == 0 {
+						redraw()
+						scr.prompt(paint(activeTheme.Error, truncateWidth("记录中没有字段: "+field, width-1)), 0)
+						continue
+					}
+					chosen := showTopMenu(breakdown, width, height)
+					scr.invalidate()
+					if chosen != nil {
+						applyFilter(topFilterLabel(view.filter, field, chosen.value), recordLines(records, chosen.lines))
+					}
+				} else if cmd == "histogram" {
+					if err := openHistogram(); err != nil {
+						return err
+					}
+					continue
+				} else if cmd == "hl" {
+					showHighlightMenu()
+					scr.invalidate()
+				} else if spec, ok := strings.CutPrefix(cmd, "hl "); ok {
+					if err := addHighlight(strings.TrimSpace(spec)); err != nil {
+						redraw()
+						scr.prompt(paint(activeTheme.Error, truncateWidth(err.Error(), width-1)), 0)
+						continue
+					}
+				} else if (strings.HasPrefix(cmd, "p ") || strings.HasPrefix(cmd, "p.")) && view.len() > 0 {
+					showPathValue(src, records, view.line(currentLine), strings.TrimPrefix(cmd, "p"))
+					scr.invalidate()
+				} else if strings.HasPrefix(cmd, "f") {
+					// 格式化指定行的 JSON
+					lineNumStr := strings.TrimPrefix(cmd, "f")
+					if lineNumStr == "" && view.len() > 0 {
+						// 如果没有指定行号，使用当前行
+						err := showFormattedJSON(src, records, view.line(currentLine))
+						if err != nil {
+							// 如果出错，仅记录错误但不退出程序
+						}
+					} else if lineNum, err := strconv.Atoi(lineNumStr); err == nil {
+						if lineNum > 0 && lineNum <= totalLines {
+							// 格式化指定行（转为 0 基索引）
+							err := showFormattedJSON(src, records, lineNum-1)
 							if err != nil {
 								// 如果出错，仅记录错误但不退出程序
 							}
-						} else if lineNum, err := strconv.Atoi(lineNumStr); err == nil {
-							if lineNum > 0 && lineNum <= totalLines {
-								// 格式化指定行（转为 0 基索引）
-								err := showFormattedJSON(filePath, lineIndex, lineNum-1)
-								if err != nil {
-									// 如果出错，仅记录错误但不退出程序
-								}
-							}
-						}
-					} else {
-						// 普通的跳转命令
-						if lineNum, err := strconv.Atoi(cmd); err == nil {
-							if lineNum > 0 && lineNum <= totalLines {
-								currentLine = lineNum - 1
-							}
 						}
 					}
-				} else if cmdType == '/' {
-					// 搜索
-					if cmd != "" {
-						searchPattern = cmd
-						// 执行搜索
-						searchMatches = searchInFile(filePath, totalLines, searchPattern)
-						if len(searchMatches) > 0 {
-							// 跳转到第一个匹配
-							currentMatchIndex = 0
-							currentLine = searchMatches[0]
+					scr.invalidate()
+				} else {
+					// 普通的跳转命令
+					if lineNum, err := strconv.Atoi(cmd); err == nil {
+						if lineNum > 0 && lineNum <= totalLines {
+							// 指定的行被过滤掉时跳到其后第一个可见行
+							currentLine = view.indexOf(lineNum - 1)
 						}
 					}
 				}
-
-				commandBuf = []byte{}
-				lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-				if err != nil {
-					return err
+			} else if cmdType == "/" {
+				// 搜索
+				if cmd != "" {
+					search.setPattern(cmd)
+					// 执行搜索，跳转到第一个匹配
+					runSearch(0)
 				}
-				lastDisplayedLine = lastLine
-				continue
-			}
-			if ch == 27 { // ESC - 取消命令
-				commandBuf = []byte{}
-				lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-				if err != nil {
-					return err
+			} else if cmdType == "&" {
+				// 过滤：只显示包含匹配的记录，输入为空时取消过滤
+				if cmd == "" {
+					applyFilter("", nil)
+				} else if lines := filterRecords(filePath, records, parseQuery(cmd)); len(lines) > 0 {
+					applyFilter(cmd, lines)
+				} else {
+					redraw()
+					scr.prompt(paint(activeTheme.Error, truncateWidth("过滤没有匹配的记录: "+cmd, width-1)), 0)
+					continue
 				}
-				lastDisplayedLine = lastLine
-				continue
 			}
-			if ch == 127 || ch == 8 { // Backspace - 删除字符
-				if len(commandBuf) > 1 {
-					commandBuf = commandBuf[:len(commandBuf)-1]
-					fmt.Print("\b \b")
-				}
-				continue
+
+			if err := redraw(); err != nil {
+				return err
 			}
-			// 添加字符到命令缓冲区（包括冒号等特殊字符）
-			commandBuf = append(commandBuf, ch)
-			fmt.Printf("%c", ch)
-			continue
-		} else if ch == ':' || ch == '/' {
-			// 开启命令模式
-			commandBuf = []byte{ch}
-			fmt.Printf("\r\n%c", ch)
 			continue
 		}
 
-		switch ch {
-		case 'q', 'Q':
+		action := keyBindings[key.name()]
+		switch action {
+		case actionCommand, actionSearch, actionFilter:
+			// 开启命令模式
+			prefix := ":"
+			if action == actionSearch {
+				prefix = "/"
+			} else if action == actionFilter {
+				prefix = "&"
+			}
+			editor = newLineEditor(prefix, history.list(prefix))
+			scr.prompt(editor.render(width))
+		case actionQuit:
 			fmt.Print("\r\n")
 			return nil
-		case 'n': // 下一个搜索匹配
-			if len(searchMatches) > 0 && currentMatchIndex >= 0 {
-				currentMatchIndex++
-				if currentMatchIndex >= len(searchMatches) {
-					currentMatchIndex = 0 // 循环到第一个
-				}
-				currentLine = searchMatches[currentMatchIndex]
-				lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-				if err != nil {
+		case actionSuspend: // Ctrl+Z - 挂起到后台，恢复后重绘
+			session.restore()
+			suspendProcess(suspendChan)
+			if err := session.enter(); err != nil {
+				return err
+			}
+			scr.invalidate()
+			if err := redraw(); err != nil {
+				return err
+			}
+		case actionNextMatch, actionPrevMatch: // 下一个 / 上一个搜索匹配（同一行中的多处匹配逐个经过）
+			delta := 1
+			if action == actionPrevMatch {
+				delta = -1
+			}
+			if m, ok := search.step(delta); ok {
+				gotoMatch(m)
+				if err := redraw(); err != nil {
 					return err
 				}
-				lastDisplayedLine = lastLine
 			}
-		case 'N': // 上一个搜索匹配
-			if len(searchMatches) > 0 && currentMatchIndex >= 0 {
-				currentMatchIndex--
-				if currentMatchIndex < 0 {
-					currentMatchIndex = len(searchMatches) - 1 // 循环到最后一个
-				}
-				currentLine = searchMatches[currentMatchIndex]
-				lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-				if err != nil {
-					return err
+		case actionToggleCase, actionToggleWord: // i / w - 切换大小写模式或整词匹配，并按新设置重新搜索
+			status := ""
+			if action == actionToggleCase {
+				searchCase = nextCaseMode(searchCase)
+				status = "搜索: " + caseModeNames[searchCase]
+			} else {
+				searchWord = !searchWord
+				status = "搜索: 整词匹配 关"
+				if searchWord {
+					status = "搜索: 整词匹配 开"
 				}
-				lastDisplayedLine = lastLine
 			}
-		case 6: // Ctrl+F - 前翻页（下一页）
+			if search.pattern != "" {
+				// 跳转到当前行及之后的第一个匹配
+				runSearch(view.line(currentLine))
+			}
+			if err := redraw(); err != nil {
+				return err
+			}
+			scr.prompt(status, 0)
+		case actionPageDown: // Ctrl+F / 空格 / PgDn - 下一页
 			// 翻页时保持连续：上一页的最后一行成为新页的第一行
-			if lastDisplayedLine < totalLines-1 {
+			if lastDisplayedLine < view.len()-1 {
 				// 如果没有显示到新的内容（当前行太长），强制往前跳一行
 				if lastDisplayedLine == currentLine {
 					currentLine++
-					if currentLine >= totalLines {
-						currentLine = totalLines - 1
+					if currentLine >= view.len() {
+						currentLine = view.len() - 1
 					}
 				} else {
 					currentLine = lastDisplayedLine
 				}
-				lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-				if err != nil {
+				if err := redraw(); err != nil {
 					return err
 				}
-				lastDisplayedLine = lastLine
 			}
-		case 2: // Ctrl+B - 后翻页（上一页）
+		case actionPageUp: // Ctrl+B / b / PgUp - 上一页
 			// vim 风格：当前页的第一行成为新页的最后一行（或最后几行之一）
 			// 策略：往前找，找到一个起始位置，使得显示后最后一行接近 currentLine
 			if currentLine > 0 {
@@ -597,7 +1108,12 @@ func interactiveMode(filePath string) error {
 						// 避免死循环
 						break
 					}
-					testLast, _ := displayPage(filePath, lineIndex, mid, totalLines, viewHeight, width, searchPattern)
+					// 只计算布局，不输出到终端
+					testFrame, err := layoutPage(src, view, mid, viewHeight, width, search, hOffset)
+					if err != nil {
+						return err
+					}
+					testLast := testFrame.lastLine
 
 					if testLast < currentLine {
 						// 显示的最后一行还没到 currentLine，起始位置太靠后了
@@ -614,101 +1130,216 @@ func interactiveMode(filePath string) error {
 				}
 
 				currentLine = bestStart
-				lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-				if err != nil {
+				if err := redraw(); err != nil {
 					return err
 				}
-				lastDisplayedLine = lastLine
 			}
-		case 'j', 'J': // j - 下一行
-			if lastDisplayedLine < totalLines-1 {
+		case actionHalfPageDown: // Ctrl+D - 向下半页
+			if lastDisplayedLine < view.len()-1 {
+				currentLine += halfPageLines(currentLine, lastDisplayedLine)
+				if currentLine >= view.len() {
+					currentLine = view.len() - 1
+				}
+				if err := redraw(); err != nil {
+					return err
+				}
+			}
+		case actionHalfPageUp: // Ctrl+U - 向上半页
+			if currentLine > 0 {
+				currentLine -= halfPageLines(currentLine, lastDisplayedLine)
+				if currentLine < 0 {
+					currentLine = 0
+				}
+				if err := redraw(); err != nil {
+					return err
+				}
+			}
+		case actionLineDown: // j / Enter / ↓ - 下一行
+			if lastDisplayedLine < view.len()-1 {
 				currentLine++
-				if currentLine >= totalLines {
-					currentLine = totalLines - 1
+				if currentLine >= view.len() {
+					currentLine = view.len() - 1
 				}
-				lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-				if err != nil {
+				if err := redraw(); err != nil {
 					return err
 				}
-				lastDisplayedLine = lastLine
 			}
-		case 'k', 'K': // k - 上一行
+		case actionLineUp: // k / ↑ - 上一行
 			if currentLine > 0 {
 				currentLine--
-				lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-				if err != nil {
+				if err := redraw(); err != nil {
 					return err
 				}
-				lastDisplayedLine = lastLine
 			}
-		case '\n', '\r': // Enter - 下一行
-			if lastDisplayedLine < totalLines-1 {
-				currentLine++
-				if currentLine >= totalLines {
-					currentLine = totalLines - 1
+		case actionWheelDown: // 鼠标滚轮向下
+			if lastDisplayedLine < view.len()-1 {
+				currentLine += wheelScrollLines
+				if currentLine > lastDisplayedLine {
+					currentLine = lastDisplayedLine
+				}
+				if err := redraw(); err != nil {
+					return err
+				}
+			}
+		case actionWheelUp: // 鼠标滚轮向上
+			if currentLine > 0 {
+				currentLine -= wheelScrollLines
+				if currentLine < 0 {
+					currentLine = 0
+				}
+				if err := redraw(); err != nil {
+					return err
+				}
+			}
+		case actionNextRecord, actionPrevRecord: // } / { - 跳到下一条 / 上一条记录的首行
+			if view.len() == 0 {
+				break
+			}
+			line := view.line(currentLine)
+			next := currentLine
+			if action == actionNextRecord {
+				if _, end := records.bounds(line); end < totalLines && view.line(view.indexOf(end)) >= end {
+					next = view.indexOf(end)
+				}
+			} else if currentLine > 0 {
+				start, _ := records.bounds(line)
+				if start == line {
+					// 已在记录首行，跳到上一条可见记录
+					start, _ = records.bounds(view.line(currentLine - 1))
+				}
+				next = view.indexOf(start)
+			}
+			if next != currentLine {
+				currentLine = next
+				if err := redraw(); err != nil {
+					return err
+				}
+			}
+		case actionToggleFold, actionFoldAll: // z / Z - 折叠或展开当前记录 / 全部记录
+			if view.len() == 0 {
+				break
+			}
+			top := view.line(currentLine)
+			start, _ := records.bounds(top)
+			if action == actionToggleFold {
+				// 当前记录是第一行所在的记录；它只有一行时，使用屏幕上第一条多行记录
+				target := -1
+				for i := currentLine; i <= lastDisplayedLine && i < view.len(); i++ {
+					if s, e := records.bounds(view.line(i)); e-s > 1 {
+						target = view.line(i)
+						break
+					}
+				}
+				if target < 0 || !view.toggleFold(target) {
+					break
 				}
-				lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
+			} else {
+				view.toggleFoldAll()
+			}
+			// 折叠后停在记录首行，展开后位置不变
+			if view.visible(top) {
+				currentLine = view.indexOf(top)
+			} else {
+				currentLine = view.indexOf(start)
+			}
+			if err := redraw(); err != nil {
+				return err
+			}
+		case actionToggleUniq: // u - 切换去重模式：合并连续的重复行
+			top := view.line(currentLine)
+			status := "去重: 关"
+			uniqOn = !uniqOn
+			if uniqOn {
+				mode := string(uniqMode)
+				if mode == uniqOff {
+					mode = uniqMask
+				}
+				groups, err := findDuplicates(filePath, totalLines, mode)
 				if err != nil {
 					return err
 				}
-				lastDisplayedLine = lastLine
+				view.dupes = groups
+				status = fmt.Sprintf("去重: 开（合并了 %d 组重复行）", len(groups))
+			} else {
+				view.dupes = nil
+			}
+			view.rebuild()
+			// 停在原来的行；它被合并时停在所在组显示的那一行
+			if head, ok := view.groupHead(top); ok {
+				top = head
+			}
+			currentLine = view.indexOf(top)
+			if search.pattern != "" {
+				runSearch(top)
 			}
-		case 'g': // 第一页
+			if err := redraw(); err != nil {
+				return err
+			}
+			scr.prompt(status, 0)
+		case actionHistogram: // H - 时间分布柱状图
+			if err := openHistogram(); err != nil {
+				return err
+			}
+		case actionTop: // 第一页
 			currentLine = 0
-			lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-			if err != nil {
+			if err := redraw(); err != nil {
 				return err
 			}
-			lastDisplayedLine = lastLine
-		case 'G': // 最后一行
+		case actionBottom: // 最后一行
 			// 跳转到最后一行
-			currentLine = totalLines - 1
+			currentLine = view.len() - 1
 			if currentLine < 0 {
 				currentLine = 0
 			}
-			lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-			if err != nil {
+			if err := redraw(); err != nil {
 				return err
 			}
-			lastDisplayedLine = lastLine
-		case 'f', 'F': // f - 格式化当前行的 JSON
+		case actionToggleChop: // S - 切换不换行模式
+			chopLongLines = !chopLongLines
+			hOffset = 0
+			if err := redraw(); err != nil {
+				return err
+			}
+		case actionScrollLeft, actionScrollRight: // ←/→ - 不换行模式下水平滚动半屏
+			if !chopLongLines {
+				break
+			}
+			step := (width - 8) / 2
+			if step < 1 {
+				step = 1
+			}
+			if action == actionScrollRight {
+				// 最多滚动到当前页最宽的一行显示到末尾；已经超出时（如翻页到较窄的行）不再右移
+				if hOffset < maxHOffset {
+					hOffset += step
+					if hOffset > maxHOffset {
+						hOffset = maxHOffset
+					}
+				}
+			} else {
+				hOffset -= step
+				if hOffset < 0 {
+					hOffset = 0
+				}
+			}
+			if err := redraw(); err != nil {
+				return err
+			}
+		case actionFormatJSON: // f - 格式化当前行的 JSON
+			if view.len() == 0 {
+				break
+			}
 			// 显示 JSON 格式化页面
-			err := showFormattedJSON(filePath, lineIndex, currentLine)
+			err := showFormattedJSON(src, records, view.line(currentLine))
 			if err != nil {
 				// 如果出错，仅记录错误但不退出程序
 				// 可以在这里显示错误信息
 			}
 			// 返回后重新显示当前页
-			lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-			if err != nil {
+			scr.invalidate()
+			if err := redraw(); err != nil {
 				return err
 			}
-			lastDisplayedLine = lastLine
-		case 27: // ESC sequence for arrow keys
-			// 读取下两个字节
-			next := make([]byte, 2)
-			os.Stdin.Read(next)
-			if next[0] == '[' {
-				if next[1] == 'A' { // 上箭头 - 上一行
-					if currentLine > 0 {
-						currentLine--
-						lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-						if err != nil {
-							return err
-						}
-						lastDisplayedLine = lastLine
-					}
-				} else if next[1] == 'B' { // 下箭头 - 下一行
-					if currentLine+1 < totalLines {
-						currentLine++
-						lastLine, err := displayPage(filePath, lineIndex, currentLine, totalLines, viewHeight, width, searchPattern)
-						if err != nil {
-							return err
-						}
-						lastDisplayedLine = lastLine
-					}
-				}
-			}
 		}
 	}
 
@@ -716,6 +1347,15 @@ func interactiveMode(filePath string) error {
 	return nil
 }
 
+// halfPageLines 计算半页滚动的行数：当前页显示行数的一半，至少 1 行
+func halfPageLines(currentLine, lastDisplayedLine int) int {
+	lines := (lastDisplayedLine - currentLine + 1) / 2
+	if lines < 1 {
+		lines = 1
+	}
+	return lines
+}
+
 // buildLineIndex 构建文件行索引（记录每行的起始位置）
 func buildLineIndex(filePath string) ([]int64, error) {
 	file, err := os.Open(filePath)
@@ -756,58 +1396,59 @@ func buildLineIndex(filePath string) ([]int64, error) {
 	return lineIndex, nil
 }
 
-// displayPage 显示指定页的内容，返回实际显示的最后一行的索引
-// 返回值：lastDisplayedLine - 实际显示的最后一行索引
-func displayPage(filePath string, lineIndex []int64, startLine, totalLines, viewHeight, termWidth int, searchPattern string) (int, error) {
-	// 清屏
-	fmt.Print("\033[2J\033[H")
+// layoutPage 计算从 startLine 开始的一页内容的布局，不输出到终端
+// hOffset 为不换行模式下的水平滚动列数
+// 返回每个屏幕行的内容，以及实际显示的最后一行的索引
+func layoutPage(src *lineReader, view *pageView, startLine, viewHeight, termWidth int, search *searchState, hOffset int) (pageFrame, error) {
+	frame := pageFrame{lastLine: startLine - 1} // lastLine 记录实际显示的最后一行
 
 	// 计算结束行
 	endLine := startLine + viewHeight*2 // 多读一些行，以防有的行很短
-	if endLine > totalLines {
-		endLine = totalLines
-	}
-
-	// 读取并显示指定范围的行
-	file, err := os.Open(filePath)
-	if err != nil {
-		return startLine, err
+	if endLine > view.len() {
+		endLine = view.len()
 	}
-	defer file.Close()
-
-	// 定位到起始行（即使 startLine=0 也要明确 seek 到开头）
-	_, err = file.Seek(lineIndex[startLine], 0)
-	if err != nil {
-		return startLine, err
-	}
-
-	scanner := bufio.NewScanner(file)
-	// 设置更大的缓冲区以处理超长行
-	scanBuf := make([]byte, 0, 64*1024)
-	scanner.Buffer(scanBuf, maxScanTokenSize)
 
 	// 计算实际使用的终端行数
 	screenLinesUsed := 0
-	lastDisplayedLine := startLine - 1 // 记录实际显示的最后一行
 
-	for i := startLine; i < endLine && scanner.Scan(); i++ {
-		line := scanner.Text()
-		if trimSpace {
-			line = strings.TrimSpace(line)
-		}
-		if unescapeFlag {
-			line = unescapeString(line)
+	for i := startLine; i < endLine; i++ {
+		lineNum := view.line(i)
+		line, err := src.line(lineNum)
+		if err != nil {
+			return frame, err
 		}
+		line = displayText(line)
+		plain := line
 
-		// 如果有搜索模式，高亮匹配的字符串
-		if searchPattern != "" {
-			line = highlightMatches(line, searchPattern)
+		// 着色：JSON 语法色在前，然后是日志等级、固定高亮，搜索高亮在最后（优先级最高）
+		var spans []styleSpan
+		if jsonColor && isJSONLine(line) {
+			spans = append(spans, jsonSyntaxSpans(line)...)
+		}
+		spans = append(spans, levelSpans(line)...)
+		spans = append(spans, highlightSpans(line)...)
+		spans = append(spans, search.spans(lineNum, line)...)
+		line = renderSpans(line, spans)
+
+		// 折叠的记录只显示首行，末尾注明隐藏的行数
+		mark := view.mark(lineNum)
+		if mark == markFolded {
+			_, end := view.records.bounds(lineNum)
+			line += paint(activeTheme.Muted, fmt.Sprintf(" … (+%d 行)", end-lineNum-1))
+		}
+		// 去重模式下合并的重复行显示次数和起止时间
+		if g, ok := findGroup(view.dupes, lineNum); ok {
+			last, err := src.line(g.last)
+			if err != nil {
+				return frame, err
+			}
+			line += paint(activeTheme.Muted, dupSummary(g.last-g.first+1, plain, displayText(last)))
 		}
 
 		// 计算这一行显示时会占用多少终端行
 		// 行号占用的宽度（如果显示行号）
-		linePrefix := ""
-		linePrefix = fmt.Sprintf("\033[%sm%6d\033[0m  ", lineNumColor, i+1)
+		// 续行的行号变暗，表示属于上方的记录
+		prefix := linePrefix(lineNum, mark)
 
 		// 计算内容宽度（考虑行号前缀的显示宽度，ANSI颜色码不占宽度）
 		prefixWidth := 8 // "  1234  " 的可见宽度
@@ -817,11 +1458,21 @@ func displayPage(filePath string, lineIndex []int64, startLine, totalLines, view
 			availableWidth = 10 // 最小宽度
 		}
 
-		// 计算这一行会占用多少屏幕行（向上取整）
-		lineLength := len(line)
-		linesNeeded := (lineLength + availableWidth - 1) / availableWidth
-		if linesNeeded == 0 {
-			linesNeeded = 1 // 空行至少占1行
+		// 按显示宽度切分为屏幕行（宽字符占 2 列，转义序列不占宽度）
+		// 最多只需要切分到一屏的高度，超出部分不会显示；不换行模式下每行只占一个屏幕行
+		var rows []string
+		more := false
+		if chopLongLines {
+			rows = []string{chopRow(line, hOffset, availableWidth)}
+			if w := displayWidth(line) - availableWidth; w > frame.maxHOffset {
+				frame.maxHOffset = w
+			}
+		} else {
+			rows, more = wrapLine(line, availableWidth, viewHeight)
+		}
+		linesNeeded := len(rows)
+		if more {
+			linesNeeded = viewHeight + 1
 		}
 
 		// 检查是否超出屏幕
@@ -834,35 +1485,31 @@ func displayPage(filePath string, lineIndex []int64, startLine, totalLines, view
 
 		// 如果这一行会超出屏幕，但剩余空间还有几行，就部分显示
 		if screenLinesUsed+linesNeeded > viewHeight && remainingLines > 0 {
-			// 截断显示：只显示能放下的部分
-			if remainingLines > 0 {
-				maxChars := remainingLines * availableWidth
-				// 注意：line 可能包含 ANSI 颜色码，直接截断可能不准确
-				// 但为了简化，我们先检查长度
-				if lineLength > maxChars && maxChars > 0 {
-					// 安全截断：确保不超出字符串范围
-					if maxChars < len(line) {
-						line = line[:maxChars] + "..."
-					}
-					linesNeeded = remainingLines
-				}
+			// 截断显示：只显示能放下的部分，并在末尾留出省略号的位置
+			if len(rows) > remainingLines {
+				rows = rows[:remainingLines]
 			}
+			last := len(rows) - 1
+			rows[last] = truncateWidth(rows[last], availableWidth-3) + "..."
+			linesNeeded = remainingLines
 		}
 
-		// 显示这一行
-		fmt.Printf("%s%s\r\n", linePrefix, line)
+		// 加入这一行，续行与内容对齐
+		for r, row := range rows {
+			if r == 0 {
+				frame.rows = append(frame.rows, prefix+row)
+			} else {
+				frame.rows = append(frame.rows, strings.Repeat(" ", prefixWidth)+row)
+			}
+		}
 
 		screenLinesUsed += linesNeeded
-		lastDisplayedLine = i // 更新实际显示的最后一行
-	}
-
-	if err := scanner.Err(); err != nil {
-		return lastDisplayedLine, err
+		frame.lastLine = i // 更新实际显示的最后一行
 	}
 
 	// 不显示状态栏
 
-	return lastDisplayedLine, nil
+	return frame, nil
 }
 
 // showHelp 显示帮助信息
@@ -871,13 +1518,34 @@ func showHelp() {
 	fmt.Println()
 	fmt.Println("用法:")
 	fmt.Println("  lg [选项] [文件路径]")
+	fmt.Println("  lg stats [选项] [文件路径]   输出日志概况（行数、时间范围、等级、服务/主机、最长的行、错误高峰）")
+	fmt.Println("  lg histogram [选项] [文件路径]  输出每分钟记录数的柱状图（按等级堆叠）")
 	fmt.Println()
 	fmt.Println("选项:")
 	fmt.Println("  -u, --unescape           替换转义符（\\n, \\t, \\r 等）")
 	fmt.Println("  -k, --keep-one-line      配合 -u 使用，保持每条日志在一行（\\n替换为空格）")
+	fmt.Println("  --escapes <list>         启用的转义符类别 (默认: basic,json,unicode,hex, 可选: octal, all)")
+	fmt.Println("  --unescape-quoted        配合 -u 使用，只替换双引号字符串内部的转义符")
 	fmt.Println("  -t, --trim               修剪每行开头和结尾的空白字符")
-	fmt.Println("  --line-color <code>      行号颜色 (默认: cyan, 选项: red, green, yellow, blue, magenta, white)")
-	fmt.Println("  --search-color <code>    搜索高亮颜色 (默认: yellow, 选项: red, green, yellow, blue, magenta, cyan)")
+	fmt.Println("  -S, --chop-long-lines    不换行显示长行，使用 ←/→ 水平滚动（交互模式中按 S 切换）")
+	fmt.Println("  --color <when>           何时输出颜色 (默认: auto, 选项: always, never；auto 时遵循 NO_COLOR)")
+	fmt.Println("  --theme <name>           配色方案 (默认: dark, 选项: light, 或配置文件中定义的方案)")
+	fmt.Println("  --line-color <color>     行号颜色 (覆盖配色方案，如 green、#5fafd7、color110)")
+	fmt.Println("  --search-color <color>   搜索高亮颜色 (覆盖配色方案，如 blue、\"black on #ffd75f\")")
+	fmt.Println("  --json-color             在分页视图中为 JSON 行着色（格式化视图始终着色）")
+	fmt.Println("  --json-theme <name>      JSON 配色 (默认跟随配色方案, 选项: default, bright, mono, 或 key=blue,number=#d7af00 形式覆盖)")
+	fmt.Println("  --search-case <mode>     搜索的大小写模式 (默认: ignore, 选项: smart 模式含大写字母时区分, sensitive)")
+	fmt.Println("  --records <mode>         多行记录的划分方式 (默认: auto, 选项: timestamp, indent, none)")
+	fmt.Println("  --record-start <regex>   记录起始行的正则表达式，不匹配的行属于上一条记录")
+	fmt.Println("  --fold                   启动时折叠所有多行记录（如堆栈）")
+	fmt.Println("  --uniq[=exact]           合并连续的重复行并显示次数（默认屏蔽数字、UUID、时间戳后比较）")
+	fmt.Println("  --patterns               将日志归类为模板，按出现次数输出模板和示例行")
+	fmt.Println("  --json                   stats、histogram 子命令以 JSON 格式输出（用于脚本）")
+	fmt.Println("  --highlight <spec>       固定高亮关键词，可重复指定（如 --highlight retry --highlight '@red trace-123'）")
+	fmt.Println("  --extract <paths>        提取每行 JSON 中指定路径的值（如 .user_id,.latency），跳过非 JSON 行")
+	fmt.Println("  -X, --no-alt-screen      不使用终端备用屏幕，退出后保留最后一页内容")
+	fmt.Println("  --mouse                  开启鼠标支持（滚轮滚动），开启后需按住 Shift 选择文本")
+	fmt.Println("  --config <path>          配置文件路径 (默认: ~/.config/loglens/config.toml)")
 	fmt.Println("  -h, --help               显示帮助信息")
 	fmt.Println()
 	fmt.Println("示例:")
@@ -886,103 +1554,55 @@ func showHelp() {
 	fmt.Println("  lg --search-color blue app.log         # 使用蓝色背景搜索高亮")
 	fmt.Println("  cat app.log | lg -u               # 从管道读取並替换转义符")
 	fmt.Println("  lg app.log > output.txt           # 输出重定向（自动使用非交互模式）")
+	fmt.Println("  lg --extract '.user_id,.latency' app.log  # 提取 JSON 字段（制表符分隔）")
+	fmt.Println("  lg --uniq app.log | less -R       # 合并重复行")
+	fmt.Println("  lg --patterns app.log             # 日志模板及出现次数")
+	fmt.Println("  lg stats --json app.log           # 日志概况（JSON 格式）")
 	fmt.Println()
 	fmt.Println("交互式模式命令:")
-	fmt.Println("  Ctrl+F          下一页")
-	fmt.Println("  Ctrl+B          上一页")
+	fmt.Println("  Ctrl+F/空格/PgDn 下一页")
+	fmt.Println("  Ctrl+B/b/PgUp   上一页")
+	fmt.Println("  Ctrl+D          向下半页")
+	fmt.Println("  Ctrl+U          向上半页")
 	fmt.Println("  j/↓             下一行")
 	fmt.Println("  k/↑             上一行")
 	fmt.Println("  Enter           下一行")
-	fmt.Println("  g               跳转到第一页")
-	fmt.Println("  G               跳转到最后一行")
+	fmt.Println("  g/Home          跳转到第一页")
+	fmt.Println("  G/End           跳转到最后一行")
 	fmt.Println("  :<行号>         跳转到指定行（例如 :100 跳转到第100行）")
 	fmt.Println("  :f              格式化当前行为 JSON")
 	fmt.Println("  :f<行号>        格式化指定行为 JSON（例如 :f5 格式化第5行）")
-	fmt.Println("  /<模式>         搜索（简单字符串搜索，不区分大小写）")
-	fmt.Println("  n               下一个搜索匹配")
+	fmt.Println("  :p <路径>       显示当前行 JSON 中指定路径的值（例如 :p .request.headers[\"x-trace-id\"]）")
+	fmt.Println("  :hl <模式>      固定高亮关键词（:hl @red <模式> 指定颜色）")
+	fmt.Println("  :hl             查看和删除固定高亮")
+	fmt.Println("  :patterns       日志模板列表，选中后只显示属于该模板的记录")
+	fmt.Println("  :top <字段>     字段各个值的记录数排行（如 :top service），选中后追加为过滤条件")
+	fmt.Println("  /<模式>         搜索（简单字符串搜索，默认不区分大小写）")
+	fmt.Println("  /<字段>:<模式>  只在 JSON/logfmt 记录的字段值中搜索（如 /message:timeout、/error.code:E42）")
+	fmt.Println("  /=<模式>        按原样搜索，不解析字段（如 /=message:timeout）")
+	fmt.Println("  i               切换搜索的大小写模式（不区分 / 智能 / 区分）")
+	fmt.Println("  w               切换整词匹配")
+	fmt.Println("  n               下一个搜索匹配（同一行中的多处匹配逐个经过）")
 	fmt.Println("  N               上一个搜索匹配")
+	fmt.Println("  &<模式>         只显示包含匹配的记录（& 后直接回车取消过滤）")
+	fmt.Println("  }/{             跳到下一条/上一条记录的首行")
+	fmt.Println("  z               折叠/展开当前的多行记录")
+	fmt.Println("  Z               折叠全部多行记录（再按一次全部展开）")
+	fmt.Println("  u               切换去重模式（合并连续的重复行）")
+	fmt.Println("  H/:histogram    时间分布柱状图，←/→ 选择时间，Enter 跳转到该时间")
 	fmt.Println("  f               格式化当前行为 JSON（快捷键）")
+	fmt.Println("  S               切换不换行模式")
+	fmt.Println("  ←/→             不换行模式下水平滚动半屏")
+	fmt.Println("  Ctrl+Z          挂起到后台（fg 恢复）")
 	fmt.Println("  q               退出")
 	fmt.Println()
-}
-
-// searchInFile 在文件中搜索匹配的行，返回匹配的行号列表
-// 使用简单的字符串搜索（不区分大小写）
-func searchInFile(filePath string, totalLines int, pattern string) []int {
-	var matches []int
-
-	if pattern == "" {
-		return matches
-	}
-
-	file, err := os.Open(filePath)
-	if err != nil {
-		return matches
-	}
-	defer file.Close()
-
-	// 转换为小写用于不区分大小写搜索
-	lowerPattern := strings.ToLower(pattern)
-
-	scanner := bufio.NewScanner(file)
-	// 设置更大的缓冲区以处理超长行
-	scanBuf := make([]byte, 0, 64*1024)
-	scanner.Buffer(scanBuf, maxScanTokenSize)
-	for i := 0; i < totalLines && scanner.Scan(); i++ {
-		line := scanner.Text()
-
-		// 应用与显示相同的处理
-		if trimSpace {
-			line = strings.TrimSpace(line)
-		}
-		if unescapeFlag {
-			line = unescapeString(line)
-		}
-
-		// 简单字符串搜索（不区分大小写）
-		if strings.Contains(strings.ToLower(line), lowerPattern) {
-			matches = append(matches, i)
-		}
-	}
-
-	return matches
-}
-
-// highlightMatches 高亮匹配的字符串（使用黄色背景 + 黑色文字）
-// 使用简单的字符串搜索（不区分大小写）
-func highlightMatches(line string, pattern string) string {
-	if pattern == "" {
-		return line
-	}
-
-	// 普通字符串搜索（不区分大小写）
-	// 需要找到原始大小写并高亮
-	lowerLine := strings.ToLower(line)
-	lowerPattern := strings.ToLower(pattern)
-
-	result := ""
-	lastIdx := 0
-
-	for {
-		idx := strings.Index(lowerLine[lastIdx:], lowerPattern)
-		if idx == -1 {
-			break
-		}
-
-		actualIdx := lastIdx + idx
-
-		// 添加匹配前的部分
-		result += line[lastIdx:actualIdx]
-
-		// 添加高亮的匹配部分（保持原始大小写）
-		matchedText := line[actualIdx : actualIdx+len(pattern)]
-		result += fmt.Sprintf("\033[%sm%s\033[0m", searchHlColor, matchedText)
-
-		lastIdx = actualIdx + len(pattern)
-	}
-
-	// 添加剩余部分
-	result += line[lastIdx:]
-
-	return result
+	fmt.Println("命令行编辑（: 和 / 输入时）:")
+	fmt.Println("  ←/→ Ctrl+B/F    左右移动光标")
+	fmt.Println("  Ctrl+A/E        移到行首/行尾")
+	fmt.Println("  Backspace/Del   删除光标前/后的字符")
+	fmt.Println("  Ctrl+W          删除光标前的一个词")
+	fmt.Println("  Ctrl+U/K        删除到行首/行尾")
+	fmt.Println("  ↑/↓ Ctrl+P/N    浏览搜索/命令历史（保存在 ~/.local/state/loglens/history）")
+	fmt.Println("  Esc/Ctrl+C      取消输入")
+	fmt.Println()
 }
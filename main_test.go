@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStdout 运行 fn 并返回其写到标准输出的内容
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+	fnErr := fn()
+	w.Close()
+	os.Stdout = stdout
+	out := <-done
+	if fnErr != nil {
+		t.Fatal(fnErr)
+	}
+	return out
+}
+
+func TestProcessStreamNoColorHighlight(t *testing.T) {
+	savedTheme, savedColor, savedHighlights := activeTheme, colorOutput, highlights
+	defer func() { activeTheme, colorOutput, highlights = savedTheme, savedColor, savedHighlights }()
+
+	// 与 --color=never 相同
+	colorOutput = false
+	activeTheme = noColorTheme
+	highlights = nil
+	for _, spec := range []string{"@red retry", "world"} {
+		if err := addHighlight(spec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := captureStdout(t, func() error {
+		return processStream(strings.NewReader("ERROR hello retry world\nINFO retry\n"))
+	})
+	if strings.Contains(out, "\033") {
+		t.Errorf("禁用颜色时输出中含有转义序列: %q", out)
+	}
+	if !strings.Contains(out, "ERROR hello retry world") {
+		t.Errorf("输出中缺少原始内容: %q", out)
+	}
+}
+
+func TestIsSubcommand(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("histogram", []byte("INFO a\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"stats"}, true},
+		{[]string{"stats", "app.log"}, true},
+		{[]string{"histogram"}, false}, // 当前目录下有同名文件
+		{[]string{"histogram", "--json"}, true},
+		{[]string{"histogram", "./histogram"}, true},
+		{[]string{"./histogram"}, false},
+		{[]string{"app.log"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isSubcommand(tt.args); got != tt.want {
+			t.Errorf("isSubcommand(%q) = %v，期望 %v", tt.args, got, tt.want)
+		}
+	}
+}
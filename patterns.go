@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// wildcard 模板中表示可变部分的占位符
+const wildcard = "<*>"
+
+// 模板挖掘的参数
+const (
+	patternSimilarity = 0.4 // 与已有模板相同的词所占比例达到该值时归入该模板（与 Drain 的默认值相同）
+	patternExamples   = 3   // 每个模板保留的示例行数
+)
+
+// logPattern 一个日志模板，以及属于它的行
+type logPattern struct {
+	tokens   []string // 模板的各个词，可变的词为 <*>
+	count    int
+	examples []string
+	lines    []int // 属于该模板的行号（只在分页器中记录）
+}
+
+// template 返回模板文本
+func (p *logPattern) template() string {
+	return strings.Join(p.tokens, " ")
+}
+
+// patternMiner 按 Drain 算法把日志行归类为模板：
+// 先按词数和第一个词分组，再在组内找相同词最多的模板，相似度足够时合并，不同的词变为 <*>
+type patternMiner struct {
+	groups    map[string][]*logPattern
+	patterns  []*logPattern
+	total     int
+	keepLines bool // 是否记录每个模板包含的行号
+}
+
+// newPatternMiner 创建模板挖掘器
+func newPatternMiner(keepLines bool) *patternMiner {
+	return &patternMiner{groups: map[string][]*logPattern{}, keepLines: keepLines}
+}
+
+// add 将一行归入模板
+func (m *patternMiner) add(line string, lineNum int) {
+	tokens := patternTokens(line)
+	if len(tokens) == 0 {
+		return
+	}
+	m.total++
+
+	key := fmt.Sprintf("%d %s", len(tokens), tokens[0])
+	var best *logPattern
+	bestScore := -1.0
+	for _, p := range m.groups[key] {
+		if score := tokenSimilarity(p.tokens, tokens); score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+	if best == nil || bestScore < patternSimilarity {
+		best = &logPattern{tokens: tokens}
+		m.groups[key] = append(m.groups[key], best)
+		m.patterns = append(m.patterns, best)
+	} else {
+		for i, t := range tokens {
+			if best.tokens[i] != t {
+				best.tokens[i] = wildcard
+			}
+		}
+	}
+
+	best.count++
+	if len(best.examples) < patternExamples {
+		best.examples = append(best.examples, line)
+	}
+	if m.keepLines {
+		best.lines = append(best.lines, lineNum)
+	}
+}
+
+// sorted 返回按行数从多到少排序的模板
+func (m *patternMiner) sorted() []*logPattern {
+	patterns := append([]*logPattern(nil), m.patterns...)
+	sort.SliceStable(patterns, func(i, j int) bool {
+		return patterns[i].count > patterns[j].count
+	})
+	return patterns
+}
+
+// patternMessageFields JSON 行中作为日志正文的字段，按顺序查找
+var patternMessageFields = []string{"message", "msg", "error", "log"}
+
+// patternLevelFields JSON 行中表示日志等级的字段
+var patternLevelFields = []string{"level", "severity", "lvl"}
+
+// patternText 返回用于归类的文本
+// JSON 行的时间戳、主机等字段各不相同，只使用等级和正文字段，例如 "WARNING Disk space low: 15% remaining"
+func patternText(line string) string {
+	if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+		return line
+	}
+	text := ""
+	for _, name := range patternMessageFields {
+		if start, end, ok := fieldRange(line, name); ok {
+			text = line[start:end]
+			break
+		}
+	}
+	if text == "" {
+		return line
+	}
+	for _, name := range patternLevelFields {
+		if start, end, ok := fieldRange(line, name); ok {
+			return line[start:end] + " " + text
+		}
+	}
+	return text
+}
+
+// patternTokens 按空白切分一行，并把明显可变的词（含数字的词）替换为 <*>
+// key=value 形式的词只替换值，保留键名；JSON 行只使用等级和正文字段
+func patternTokens(line string) []string {
+	tokens := strings.Fields(patternText(line))
+	for i, t := range tokens {
+		if !strings.ContainsAny(t, "0123456789") {
+			continue
+		}
+		if key, _, ok := strings.Cut(t, "="); ok && key != "" && !strings.ContainsAny(key, "0123456789") {
+			tokens[i] = key + "=" + wildcard
+		} else {
+			tokens[i] = wildcard
+		}
+	}
+	return tokens
+}
+
+// tokenSimilarity 两组词数相同的词中，位置相同且内容相同的比例
+// <*> 不算作相同，否则变量越多的模板越容易吸收不相关的行；
+// 但两组词完全相同（包括 <*> 的位置）时相似度为 1，否则大部分是变量的行（如访问日志）
+// 永远无法归入与自己相同的模板
+func tokenSimilarity(template, tokens []string) float64 {
+	same, equal := 0, true
+	for i, t := range template {
+		if t != tokens[i] {
+			equal = false
+		} else if t != wildcard {
+			same++
+		}
+	}
+	if equal {
+		return 1
+	}
+	return float64(same) / float64(len(template))
+}
+
+// minePatterns 读取整个输入并归类每条记录的首行（续行如堆栈不参与归类）
+// 行的处理与非交互模式的输出一致（-t 修剪空白、-u 替换转义符）
+func minePatterns(reader io.Reader) (*patternMiner, error) {
+	scanner := newLogScanner(reader)
+	splitter, err := newRecordSplitter()
+	if err != nil {
+		return nil, err
+	}
+	miner := newPatternMiner(false)
+	for lineNum := 0; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if !splitter.isStart(line) {
+			continue
+		}
+		miner.add(displayText(line), lineNum)
+	}
+	return miner, scanner.Err()
+}
+
+// printPatterns 按频率输出模板列表，每个模板附带示例行（--patterns）
+func printPatterns(reader io.Reader) error {
+	miner, err := minePatterns(reader)
+	if err != nil {
+		return err
+	}
+	patterns := miner.sorted()
+	fmt.Printf("%s\n\n", paint(activeTheme.Title, fmt.Sprintf("共 %d 条记录，%d 个模板", miner.total, len(patterns))))
+	for _, p := range patterns {
+		fmt.Printf("%s %s  %s\n", paint(activeTheme.Gutter, fmt.Sprintf("%8d", p.count)),
+			fmt.Sprintf("%5.1f%%", float64(p.count)*100/float64(miner.total)), paintTemplate(p.template()))
+		for _, example := range p.examples {
+			fmt.Printf("%17s%s\n", "", paint(activeTheme.Muted, example))
+		}
+	}
+	return nil
+}
+
+// paintTemplate 将模板中的 <*> 以暗色显示
+func paintTemplate(template string) string {
+	return strings.ReplaceAll(template, wildcard, paint(activeTheme.Muted, wildcard))
+}
+
+// minePatternsInFile 在分页器中归类文件的每条记录，记录每个模板包含的记录首行
+func minePatternsInFile(filePath string, records recordIndex) ([]*logPattern, int, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	scanner := newLogScanner(file)
+	miner := newPatternMiner(true)
+	for i := 0; i < records.total && scanner.Scan(); i++ {
+		if records.isStart(i) {
+			miner.add(displayText(scanner.Text()), i)
+		}
+	}
+	return miner.sorted(), miner.total, scanner.Err()
+}
+
+// showPatternMenu 显示模板列表（:patterns 命令），返回选中的模板，按 q/Esc 退出时返回 nil
+// 选中模板的示例行显示在列表下方
+func showPatternMenu(patterns []*logPattern, total, width, height int) *logPattern {
+	selected, top := 0, 0
+	for {
+		listHeight := height - 4 - patternExamples - 1
+		if listHeight < 1 {
+			listHeight = 1
+		}
+		if selected < top {
+			top = selected
+		}
+		if selected >= top+listHeight {
+			top = selected - listHeight + 1
+		}
+
+		fmt.Print("\033[2J\033[H")
+		fmt.Print(paint(activeTheme.Title, fmt.Sprintf("=== 日志模板（%d 条记录，%d 个模板）===", total, len(patterns))) + "\r\n\r\n")
+		for i := top; i < len(patterns) && i < top+listHeight; i++ {
+			p := patterns[i]
+			marker := "  "
+			if i == selected {
+				marker = "> "
+			}
+			text := fmt.Sprintf("%s%8d %5.1f%%  ", marker, p.count, float64(p.count)*100/float64(total))
+			if avail := width - displayWidth(text) - 1; avail > 0 {
+				text += truncateWidth(p.template(), avail)
+			}
+			if i == selected {
+				text = paint(activeTheme.Search, text)
+			} else {
+				text = paintTemplate(text)
+			}
+			fmt.Print(text + "\r\n")
+		}
+		fmt.Print("\r\n")
+		if len(patterns) > 0 {
+			for _, example := range patterns[selected].examples {
+				fmt.Print(paint(activeTheme.Muted, "  "+truncateWidth(example, width-3)) + "\r\n")
+			}
+		}
+		fmt.Printf("\033[%d;1H%s", height, paint(activeTheme.Muted, "j/k 选择  Enter 只显示该模板的记录  q/Esc 返回"))
+
+		key, err := keyInput.nextKey()
+		if err != nil {
+			return nil
+		}
+		switch key.name() {
+		case "j", "down":
+			if selected < len(patterns)-1 {
+				selected++
+			}
+		case "k", "up":
+			if selected > 0 {
+				selected--
+			}
+		case "ctrl+f", "space", "pgdn":
+			selected += listHeight
+			if selected > len(patterns)-1 {
+				selected = len(patterns) - 1
+			}
+		case "ctrl+b", "b", "pgup":
+			selected -= listHeight
+			if selected < 0 {
+				selected = 0
+			}
+		case "enter":
+			if len(patterns) > 0 {
+				return patterns[selected]
+			}
+			return nil
+		case "q", "esc":
+			return nil
+		}
+	}
+}
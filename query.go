@@ -0,0 +1,218 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// searchQuery 解析后的搜索模式
+//
+//	timeout          在整行中查找
+//	message:timeout  只在 JSON / logfmt 记录的 message 字段值中查找（嵌套字段用 . 分隔，如 error.code:E42）
+//	=message:timeout 以 = 开头时按原样查找，不解析字段
+type searchQuery struct {
+	text    string // 要查找的文本
+	field   string // 限定的字段名，为空表示整行
+	literal string // 行中没有该字段时按整体查找的文本
+}
+
+// parseQuery 解析搜索模式
+func parseQuery(pattern string) searchQuery {
+	if rest, ok := strings.CutPrefix(pattern, "="); ok {
+		return searchQuery{text: rest, literal: rest}
+	}
+	if field, text, ok := strings.Cut(pattern, ":"); ok && text != "" && isFieldName(field) {
+		return searchQuery{text: text, field: field, literal: pattern}
+	}
+	return searchQuery{text: pattern, literal: pattern}
+}
+
+// isFieldName 检查字符串是否可以作为字段名（字母、数字、_、-、@、$，嵌套字段用 . 分隔）
+func isFieldName(s string) bool {
+	if s == "" || s[0] == '.' || s[len(s)-1] == '.' || strings.Contains(s, "..") {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if !isWordByte(ch) && ch != '.' && ch != '-' && ch != '@' && ch != '$' {
+			return false
+		}
+	}
+	return !(s[0] >= '0' && s[0] <= '9')
+}
+
+// find 返回行中所有匹配的字节区间
+// 字段查询在 JSON 行中按路径查找字段值，在其他行中查找 logfmt 的 key=value；
+// 行中没有该字段时（如普通文本 "ERROR: timeout"）按整个模式查找
+func (q searchQuery) find(line string) [][2]int {
+	if q.text == "" {
+		return nil
+	}
+	if q.field != "" {
+		if start, end, ok := fieldRange(line, q.field); ok {
+			ranges := findMatches(line[start:end], q.text)
+			for i := range ranges {
+				ranges[i][0] += start
+				ranges[i][1] += start
+			}
+			return ranges
+		}
+	}
+	return findMatches(line, q.literal)
+}
+
+// fieldRange 返回行中字段值的字节区间，字符串值不含两侧的引号
+func fieldRange(line, field string) (int, int, bool) {
+	i := skipJSONSpace(line, 0)
+	if i < len(line) && line[i] == '{' {
+		return jsonFieldRange(line, i, strings.Split(field, "."))
+	}
+	return logfmtFieldRange(line, field)
+}
+
+// jsonFieldRange 在从 i 开始的 JSON 对象中按键名路径查找值的区间
+// 直接扫描原始文本而不是解析后再序列化，区间与显示内容中的位置一致
+func jsonFieldRange(s string, i int, keys []string) (int, int, bool) {
+	for _, key := range keys {
+		if i >= len(s) || s[i] != '{' {
+			return 0, 0, false
+		}
+		i++
+		for {
+			i = skipJSONSpace(s, i)
+			if i >= len(s) || s[i] != '"' {
+				return 0, 0, false
+			}
+			end := scanJSONString(s, i)
+			if end-1 <= i || s[end-1] != '"' {
+				return 0, 0, false
+			}
+			name := s[i+1 : end-1]
+			i = skipJSONSpace(s, end)
+			if i >= len(s) || s[i] != ':' {
+				return 0, 0, false
+			}
+			i = skipJSONSpace(s, i+1)
+			if name == key {
+				break
+			}
+			i = skipJSONSpace(s, skipJSONValue(s, i))
+			if i >= len(s) || s[i] != ',' {
+				return 0, 0, false
+			}
+			i++
+		}
+	}
+
+	if i >= len(s) {
+		return 0, 0, false
+	}
+	end := skipJSONValue(s, i)
+	if s[i] == '"' && end-1 > i && s[end-1] == '"' {
+		return i + 1, end - 1, true
+	}
+	return i, end, true
+}
+
+// skipJSONSpace 跳过 JSON 空白字符
+func skipJSONSpace(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\r' || s[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// skipJSONValue 返回从 i 开始的 JSON 值之后的位置，对象和数组整体跳过
+func skipJSONValue(s string, i int) int {
+	if i >= len(s) {
+		return i
+	}
+	switch s[i] {
+	case '"':
+		return scanJSONString(s, i)
+	case '{', '[':
+		depth := 0
+		for i < len(s) {
+			switch s[i] {
+			case '"':
+				i = scanJSONString(s, i)
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+			i++
+		}
+		return i
+	}
+	for i < len(s) && !strings.ContainsRune(",}] \t\r\n", rune(s[i])) {
+		i++
+	}
+	return i
+}
+
+// logfmtFieldRange 在 logfmt 格式（key=value 或 key="带空格的值"）的行中查找字段值的区间
+func logfmtFieldRange(s, key string) (int, int, bool) {
+	for i := 0; i < len(s); {
+		for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+			i++
+		}
+		start := i
+		for i < len(s) && s[i] != '=' && s[i] != ' ' && s[i] != '\t' {
+			i++
+		}
+		if i >= len(s) || s[i] != '=' {
+			continue
+		}
+		name := s[start:i]
+		i++
+
+		valueStart, valueEnd := i, i
+		if i < len(s) && s[i] == '"' {
+			end := scanJSONString(s, i)
+			valueStart, valueEnd = i+1, end
+			if end-1 > i && s[end-1] == '"' {
+				valueEnd = end - 1
+			}
+			i = end
+		} else {
+			for i < len(s) && s[i] != ' ' && s[i] != '\t' {
+				i++
+			}
+			valueEnd = i
+		}
+		if name == key {
+			return valueStart, valueEnd, true
+		}
+	}
+	return 0, 0, false
+}
+
+// isWordRune 字符是否属于单词（字母、数字、下划线）
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// wholeWordAt 检查 line 中 [start, end) 的匹配两侧是否为单词边界
+// 只检查模式本身以单词字符开头或结尾的一侧，因此 "id=12" 不会匹配 "id=123"，
+// 而 "=12" 仍能匹配 "id=12"
+func wholeWordAt(line string, start, end int, pattern string) bool {
+	first, _ := utf8.DecodeRuneInString(pattern)
+	if isWordRune(first) && start > 0 {
+		if r, _ := utf8.DecodeLastRuneInString(line[:start]); isWordRune(r) {
+			return false
+		}
+	}
+	last, _ := utf8.DecodeLastRuneInString(pattern)
+	if isWordRune(last) && end < len(line) {
+		if r, _ := utf8.DecodeRuneInString(line[end:]); isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 记录划分模式（--records）
+// 一条逻辑记录由一个起始行和后面的续行（堆栈、缩进的内容等）组成
+const (
+	recordsAuto      = "auto"      // 上一条记录以时间戳开头时按时间戳划分，否则按缩进划分（默认）
+	recordsTimestamp = "timestamp" // 以时间戳开头的行（以及 JSON 行）开始新记录
+	recordsIndent    = "indent"    // 缩进的行、空行和 "Caused by:" 等行属于上一条记录
+	recordsNone      = "none"      // 每行都是一条记录
+)
+
+// timestampPrefix 行首的常见时间戳格式
+//
+//	2024-01-02 15:04:05、2024-01-02T15:04:05Z、[2024/01/02 15:04]、15:04:05.123、
+//	Jan  2 15:04:05（syslog）、ts=... / time=...（logfmt）
+var timestampPrefix = regexp.MustCompile(`^\[?(\d{4}[-/.]\d{2}[-/.]\d{2}[T ]\d{2}:\d{2}|\d{2}:\d{2}:\d{2}|[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}|(ts|time|timestamp)=)`)
+
+// continuationPrefixes 按缩进划分时，不缩进但仍属于上一条记录的行的开头
+// 分别对应 Java 的异常链、省略的栈帧，以及多行 JSON 的结尾
+var continuationPrefixes = []string{"Caused by:", "Suppressed:", "... ", "}", "]"}
+
+// recordSplitter 逐行判断是否开始一条新记录，文件和标准输入共用
+type recordSplitter struct {
+	mode    string
+	pattern *regexp.Regexp // --record-start 指定的起始行模式
+	started bool           // 已经读到过第一行
+	stamped bool           // 当前记录的首行以时间戳开头
+}
+
+// newRecordSplitter 按 --records 和 --record-start 参数创建划分器
+func newRecordSplitter() (*recordSplitter, error) {
+	s := &recordSplitter{mode: recordMode}
+	switch recordMode {
+	case recordsAuto, recordsTimestamp, recordsIndent, recordsNone:
+	default:
+		return nil, fmt.Errorf("--records 的值必须是 auto、timestamp、indent 或 none: %s", recordMode)
+	}
+	if recordStart != "" {
+		re, err := regexp.Compile(recordStart)
+		if err != nil {
+			return nil, fmt.Errorf("--record-start 不是合法的正则表达式: %v", err)
+		}
+		s.pattern = re
+	}
+	return s, nil
+}
+
+// isStart 判断一行是否开始新记录，需要按顺序对每一行调用
+// 第一行总是开始新记录
+func (s *recordSplitter) isStart(line string) bool {
+	start := s.startsRecord(line)
+	if !s.started {
+		s.started = true
+		start = true
+	}
+	if start {
+		s.stamped = timestampPrefix.MatchString(line)
+	}
+	return start
+}
+
+// startsRecord 按划分模式判断一行是否是记录的起始行
+func (s *recordSplitter) startsRecord(line string) bool {
+	if s.pattern != nil {
+		return s.pattern.MatchString(line)
+	}
+	switch s.mode {
+	case recordsNone:
+		return true
+	case recordsTimestamp:
+		return timestampPrefix.MatchString(line) || strings.HasPrefix(line, "{")
+	case recordsAuto:
+		if s.stamped {
+			return timestampPrefix.MatchString(line) || strings.HasPrefix(line, "{")
+		}
+	}
+	return !isIndentContinuation(line)
+}
+
+// isIndentContinuation 按缩进规则判断一行是否属于上一条记录
+func isIndentContinuation(line string) bool {
+	if strings.TrimSpace(line) == "" || line[0] == ' ' || line[0] == '\t' {
+		return true
+	}
+	for _, prefix := range continuationPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordIndex 文件中逻辑记录的划分
+type recordIndex struct {
+	starts []int // 每条记录首行的行号（升序），为 nil 表示每行都是一条记录
+	total  int   // 文件总行数
+}
+
+// buildRecordIndex 读取文件，找出每条记录的首行
+func buildRecordIndex(filePath string, totalLines int) (recordIndex, error) {
+	index := recordIndex{total: totalLines}
+	splitter, err := newRecordSplitter()
+	if err != nil {
+		return index, err
+	}
+	if splitter.mode == recordsNone && splitter.pattern == nil {
+		return index, nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return index, err
+	}
+	defer file.Close()
+
+	scanner := newLogScanner(file)
+	var starts []int
+	for i := 0; i < totalLines && scanner.Scan(); i++ {
+		if splitter.isStart(scanner.Text()) {
+			starts = append(starts, i)
+		}
+	}
+	// 没有任何续行时不保存，节省内存
+	if len(starts) < totalLines {
+		index.starts = starts
+	}
+	return index, nil
+}
+
+// bounds 返回第 line 行所在记录的行范围 [start, end)
+func (r recordIndex) bounds(line int) (int, int) {
+	if r.starts == nil {
+		return line, line + 1
+	}
+	k := sort.SearchInts(r.starts, line+1) - 1
+	if k < 0 {
+		return 0, 1
+	}
+	end := r.total
+	if k+1 < len(r.starts) {
+		end = r.starts[k+1]
+	}
+	return r.starts[k], end
+}
+
+// isStart 第 line 行是否是记录的首行
+func (r recordIndex) isStart(line int) bool {
+	start, _ := r.bounds(line)
+	return start == line
+}
+
+// recordText 读取第 line 行所在记录的全部内容（按 -t 修剪每行），返回内容和行范围
+func recordText(src *lineReader, records recordIndex, line int) (string, int, int, error) {
+	start, end := records.bounds(line)
+	lines := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		s, err := readTrimmedLine(src, i)
+		if err != nil {
+			return "", start, end, err
+		}
+		lines = append(lines, s)
+	}
+	return strings.Join(lines, "\n"), start, end, nil
+}
+
+// pageView 分页器中可见的行：过滤后保留下来的记录中的行，去掉折叠的记录的续行
+type pageView struct {
+	records  recordIndex
+	filter   string       // 当前的过滤模式，为空表示不过滤
+	filtered []int        // 过滤后保留的行号（升序），为 nil 表示不过滤
+	folded   map[int]bool // 折叠的记录（以首行行号为键），只显示一行摘要
+	dupes    []dupGroup   // 去重模式下合并的连续重复行，每组只显示第一行
+	lines    []int        // 可见的行号（升序），为 nil 表示全部可见
+}
+
+// setFilter 设置过滤结果，filter 为空时取消过滤
+func (v *pageView) setFilter(filter string, lines []int) {
+	v.filter, v.filtered = filter, lines
+	v.rebuild()
+}
+
+// rebuild 根据过滤结果、折叠状态和去重结果重新计算可见的行
+func (v *pageView) rebuild() {
+	if len(v.folded) == 0 && len(v.dupes) == 0 {
+		v.lines = v.filtered
+		return
+	}
+	lines := []int{}
+	add := func(line int) {
+		if start, _ := v.records.bounds(line); start != line && v.folded[start] {
+			return
+		}
+		if v.duplicate(line) {
+			return
+		}
+		lines = append(lines, line)
+	}
+	if v.filtered != nil {
+		for _, line := range v.filtered {
+			add(line)
+		}
+	} else {
+		for i := 0; i < v.records.total; i++ {
+			add(i)
+		}
+	}
+	v.lines = lines
+}
+
+// duplicate 第 line 行是否因为与上一行重复而被隐藏
+func (v *pageView) duplicate(line int) bool {
+	head, ok := v.groupHead(line)
+	return ok && head != line
+}
+
+// groupHead 返回第 line 行所在的重复行组中显示的那一行：组中第一个在过滤结果中的行
+// 过滤只保留了组中后面的行时（如 &id=3 只匹配 "req id=3 retry"），显示该行而不是组的第一行
+func (v *pageView) groupHead(line int) (int, bool) {
+	g, ok := findGroup(v.dupes, line)
+	if !ok {
+		return line, false
+	}
+	if v.filtered == nil {
+		return g.first, true
+	}
+	if i := sort.SearchInts(v.filtered, g.first); i < len(v.filtered) && v.filtered[i] <= g.last {
+		return v.filtered[i], true
+	}
+	return line, true
+}
+
+// toggleFold 折叠或展开第 line 行所在的记录，返回该记录是否跨多行
+func (v *pageView) toggleFold(line int) bool {
+	start, end := v.records.bounds(line)
+	if end-start < 2 {
+		return false
+	}
+	if v.folded[start] {
+		delete(v.folded, start)
+	} else {
+		if v.folded == nil {
+			v.folded = map[int]bool{}
+		}
+		v.folded[start] = true
+	}
+	v.rebuild()
+	return true
+}
+
+// unfold 展开第 line 行所在的记录（如跳转到折叠的记录中的搜索匹配时）
+func (v *pageView) unfold(line int) {
+	if start, _ := v.records.bounds(line); v.folded[start] {
+		delete(v.folded, start)
+		v.rebuild()
+	}
+}
+
+// toggleFoldAll 有折叠的记录时全部展开，否则折叠所有跨多行的记录
+func (v *pageView) toggleFoldAll() {
+	if len(v.folded) > 0 {
+		v.folded = nil
+	} else {
+		v.folded = map[int]bool{}
+		for k, start := range v.records.starts {
+			end := v.records.total
+			if k+1 < len(v.records.starts) {
+				end = v.records.starts[k+1]
+			}
+			if end-start > 1 {
+				v.folded[start] = true
+			}
+		}
+	}
+	v.rebuild()
+}
+
+// len 返回可见的行数
+func (v *pageView) len() int {
+	if v.lines == nil {
+		return v.records.total
+	}
+	return len(v.lines)
+}
+
+// line 返回第 i 个可见行的行号，没有可见行时返回 -1
+func (v *pageView) line(i int) int {
+	if i < 0 || i >= v.len() {
+		return -1
+	}
+	if v.lines == nil {
+		return i
+	}
+	return v.lines[i]
+}
+
+// indexOf 返回第 line 行在可见行中的位置；该行不可见时返回其后第一个可见行的位置
+func (v *pageView) indexOf(line int) int {
+	if v.lines == nil {
+		return clampIndex(line, v.len())
+	}
+	return clampIndex(sort.SearchInts(v.lines, line), v.len())
+}
+
+// visible 第 line 行是否可见
+func (v *pageView) visible(line int) bool {
+	if v.lines == nil {
+		return line >= 0 && line < v.records.total
+	}
+	i := sort.SearchInts(v.lines, line)
+	return i < len(v.lines) && v.lines[i] == line
+}
+
+// inFilter 第 line 行是否在过滤结果中（不考虑折叠和去重）
+func (v *pageView) inFilter(line int) bool {
+	if v.filtered == nil {
+		return line >= 0 && line < v.records.total
+	}
+	i := sort.SearchInts(v.filtered, line)
+	return i < len(v.filtered) && v.filtered[i] == line
+}
+
+// 行号后的标记，表示行在记录中的位置和折叠状态
+const (
+	markNone         = " " // 单行记录
+	markExpanded     = "▾" // 展开的多行记录的首行
+	markFolded       = "▸" // 折叠的记录
+	markContinuation = "│" // 续行
+)
+
+// mark 返回第 line 行的标记
+func (v *pageView) mark(line int) string {
+	start, end := v.records.bounds(line)
+	switch {
+	case start != line:
+		return markContinuation
+	case end-start == 1:
+		return markNone
+	case v.folded[start]:
+		return markFolded
+	}
+	return markExpanded
+}
+
+// clampIndex 将下标限制在 [0, n) 范围内
+func clampIndex(i, n int) int {
+	if i >= n {
+		i = n - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+// filterRecords 返回包含匹配的记录中的所有行，任意一行匹配即保留整条记录
+func filterRecords(filePath string, records recordIndex, query searchQuery) []int {
+	var matched []int
+	for _, m := range searchInFile(filePath, records.total, query) {
+		matched = append(matched, m.line)
+	}
+	return recordLines(records, matched)
+}
+
+// recordLines 返回 matched 中各行所在的记录包含的所有行（matched 需升序）
+func recordLines(records recordIndex, matched []int) []int {
+	var lines []int
+	for _, s := range matched {
+		start, end := records.bounds(s)
+		if len(lines) > 0 && lines[len(lines)-1] >= start {
+			continue
+		}
+		for i := start; i < end; i++ {
+			lines = append(lines, i)
+		}
+	}
+	return lines
+}
+
+// linePrefix 返回行号前缀（占 8 列）：行号、标记和一个空格
+// 续行的行号变暗并以 │ 与内容分隔，表示属于上方的记录
+func linePrefix(line int, mark string) string {
+	if mark == markContinuation {
+		return paint(activeTheme.Muted, fmt.Sprintf("%6d%s", line+1, mark)) + " "
+	}
+	return paint(activeTheme.Gutter, fmt.Sprintf("%6d", line+1)) + paint(activeTheme.Muted, mark) + " "
+}
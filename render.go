@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// lineCacheSize 行内容缓存的最大条目数
+const lineCacheSize = 1024
+
+// lineReader 按行号读取文件内容
+// 在分页期间保持文件打开，并缓存最近读取的行，避免每次重绘都重新打开和定位文件
+type lineReader struct {
+	file       *os.File
+	lineIndex  []int64
+	totalLines int
+	size       int64
+	cache      map[int]string
+}
+
+// newLineReader 打开文件并创建行读取器
+func newLineReader(filePath string, lineIndex []int64, totalLines int) (*lineReader, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &lineReader{
+		file:       file,
+		lineIndex:  lineIndex,
+		totalLines: totalLines,
+		size:       info.Size(),
+		cache:      make(map[int]string),
+	}, nil
+}
+
+// Close 关闭文件
+func (r *lineReader) Close() error {
+	return r.file.Close()
+}
+
+// line 读取第 n 行（0 基索引）的原始内容，不包含行尾的换行符
+func (r *lineReader) line(n int) (string, error) {
+	if n < 0 || n >= r.totalLines {
+		return "", fmt.Errorf("行号超出范围")
+	}
+	if s, ok := r.cache[n]; ok {
+		return s, nil
+	}
+
+	start := r.lineIndex[n]
+	end := r.size
+	if n+1 < len(r.lineIndex) {
+		end = r.lineIndex[n+1]
+	}
+	// 与 Scanner 的缓冲区上限保持一致，防止超长行占用过多内存
+	if end-start > maxScanTokenSize {
+		end = start + maxScanTokenSize
+	}
+
+	buf := make([]byte, end-start)
+	if _, err := r.file.ReadAt(buf, start); err != nil && err != io.EOF {
+		return "", err
+	}
+	s := strings.TrimSuffix(strings.TrimSuffix(string(buf), "\n"), "\r")
+
+	if len(r.cache) >= lineCacheSize {
+		r.cache = make(map[int]string)
+	}
+	r.cache[n] = s
+	return s, nil
+}
+
+// pageFrame 一页的布局结果：每个屏幕行的内容和实际显示的最后一行
+type pageFrame struct {
+	rows       []string
+	lastLine   int
+	maxHOffset int // 不换行模式下水平滚动的最大列数：最宽的一行刚好显示到末尾
+}
+
+// maxScrollLines 使用滚动区域优化重绘的最大滚动行数
+const maxScrollLines = 3
+
+// screen 终端输出缓冲
+// 记录上一次绘制的各屏幕行，只重写发生变化的行；
+// 内容整体上下移动少量行时使用终端滚动区域，减少重绘和闪烁
+type screen struct {
+	out    *bufio.Writer
+	height int      // 可用于显示内容的行数（不含底部命令行）
+	rows   []string // 上一次绘制的内容
+	status string   // 底部状态栏内容
+	valid  bool     // rows 是否与终端实际内容一致
+}
+
+// newScreen 创建输出缓冲
+func newScreen(height int) *screen {
+	return &screen{out: bufio.NewWriterSize(os.Stdout, 64*1024), height: height}
+}
+
+// resize 终端大小变化后调整高度，下次绘制时全部重画
+func (s *screen) resize(height int) {
+	s.height = height
+	s.invalidate()
+}
+
+// invalidate 标记屏幕内容已被其他输出覆盖（如 JSON 格式化页面），下次绘制时全部重画
+func (s *screen) invalidate() {
+	s.valid = false
+}
+
+// draw 绘制一帧内容和底部状态栏，只输出与上一帧不同的行
+func (s *screen) draw(rows []string, status string) {
+	next := make([]string, s.height)
+	copy(next, rows)
+
+	if !s.valid {
+		s.out.WriteString("\033[2J")
+		s.rows = make([]string, s.height)
+		for i := range s.rows {
+			s.rows[i] = "\x00" // 保证每一行都会被重画
+		}
+	} else {
+		s.scrollTo(next)
+	}
+
+	for i, row := range next {
+		if row == s.rows[i] {
+			continue
+		}
+		// 先清除整行再写入，避免写满整行后清除到行尾误删最后一列
+		fmt.Fprintf(s.out, "\033[%d;1H\033[2K%s", i+1, row)
+	}
+	// 底部行显示状态栏（可能被命令行覆盖过，每次都重写），光标停在该行开头
+	fmt.Fprintf(s.out, "\033[%d;1H\033[2K%s\033[%d;1H", s.height+1, status, s.height+1)
+	s.out.Flush()
+
+	s.rows = next
+	s.status = status
+	s.valid = true
+}
+
+// scrollTo 检查新一帧是否为上一帧上下平移若干行的结果
+// 如果是，则使用滚动区域移动终端内容，并同步更新 rows 记录
+func (s *screen) scrollTo(next []string) {
+	h := s.height
+	for n := 1; n <= maxScrollLines && n < h; n++ {
+		if equalRows(next[:h-n], s.rows[n:]) && next[h-n-1] != "" {
+			// 内容上移 n 行（向下滚动查看）
+			fmt.Fprintf(s.out, "\033[1;%dr\033[%dS\033[r", h, n)
+			s.rows = append(append([]string{}, s.rows[n:]...), make([]string, n)...)
+			return
+		}
+		if equalRows(next[n:], s.rows[:h-n]) && next[n] != "" {
+			// 内容下移 n 行（向上滚动查看）
+			fmt.Fprintf(s.out, "\033[1;%dr\033[%dT\033[r", h, n)
+			s.rows = append(make([]string, n), s.rows[:h-n]...)
+			return
+		}
+	}
+}
+
+// equalRows 比较两组屏幕行是否完全相同
+func equalRows(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// statusLine 生成状态栏：左侧为文件名、过滤和搜索状态，右侧为当前显示的行范围和位置百分比
+// first、last 为 0 基的可见行位置，total 为可见行数；matchIndex 为当前匹配的序号（0 基），
+// 没有过滤时 filter 为空，没有搜索时 pattern 为空
+func statusLine(width int, name, filter string, first, last, total int, pattern string, matchIndex, matchCount int) string {
+	left := " " + name
+	if filter != "" {
+		left += "  &" + filter
+	}
+	if pattern != "" {
+		if matchCount == 0 {
+			left += fmt.Sprintf("  /%s（无匹配）", pattern)
+		} else {
+			left += fmt.Sprintf("  /%s %d/%d", pattern, matchIndex+1, matchCount)
+		}
+	}
+	percent := 100
+	if total > 0 {
+		percent = (last + 1) * 100 / total
+	}
+	right := fmt.Sprintf("%d-%d/%d  %3d%% ", first+1, last+1, total, percent)
+
+	// 宽度不够时优先保留右侧的位置信息
+	if avail := width - displayWidth(right) - 1; displayWidth(left) > avail {
+		if avail < 1 {
+			left = ""
+		} else {
+			left = truncateWidth(left, avail)
+		}
+	}
+	pad := width - displayWidth(left) - displayWidth(right)
+	if pad < 1 {
+		pad = 1
+	}
+	return paint(activeTheme.Status, left+strings.Repeat(" ", pad)+right)
+}
+
+// prompt 在底部命令行显示提示内容，光标停在第 cursor 列（0 基）
+func (s *screen) prompt(text string, cursor int) {
+	fmt.Fprintf(s.out, "\033[%d;1H\033[2K%s\033[%d;%dH", s.height+1, text, s.height+1, cursor+1)
+	s.out.Flush()
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// searchMatch 一处搜索匹配：所在行（0 基）和在显示内容中的字节区间 [start, end)
+// 显示内容指经过 -t、-u 处理后的行
+type searchMatch struct {
+	line  int
+	start int
+	end   int
+}
+
+// searchState 分页器的搜索状态
+type searchState struct {
+	pattern string
+	query   searchQuery
+	matches []searchMatch // 按行号和位置排序的所有匹配
+	index   int           // 当前匹配在 matches 中的下标，-1 表示没有当前匹配
+}
+
+// newSearchState 创建空的搜索状态
+func newSearchState() *searchState {
+	return &searchState{index: -1}
+}
+
+// current 返回当前匹配
+func (s *searchState) current() (searchMatch, bool) {
+	if s.index < 0 || s.index >= len(s.matches) {
+		return searchMatch{}, false
+	}
+	return s.matches[s.index], true
+}
+
+// step 移动到下一个（delta 为 1）或上一个（delta 为 -1）匹配，到达两端时循环
+// 同一行中的多处匹配会逐个经过
+func (s *searchState) step(delta int) (searchMatch, bool) {
+	if len(s.matches) == 0 || s.index < 0 {
+		return searchMatch{}, false
+	}
+	s.index = (s.index + delta + len(s.matches)) % len(s.matches)
+	return s.matches[s.index], true
+}
+
+// setPattern 设置新的搜索模式
+func (s *searchState) setPattern(pattern string) {
+	s.pattern = pattern
+	s.query = parseQuery(pattern)
+}
+
+// spans 返回第 lineNum 行中所有匹配的高亮区间，当前匹配使用单独的样式
+func (s *searchState) spans(lineNum int, line string) []styleSpan {
+	if s.pattern == "" {
+		return nil
+	}
+	cur, hasCurrent := s.current()
+	var spans []styleSpan
+	for _, r := range s.query.find(line) {
+		code := activeTheme.Search
+		if hasCurrent && cur.line == lineNum && cur.start == r[0] {
+			code = activeTheme.CurrentMatch
+		}
+		spans = append(spans, styleSpan{start: r[0], end: r[1], code: code})
+	}
+	return spans
+}
+
+// displayText 对原始行应用与显示相同的处理（-t 修剪空白、-u 替换转义符）
+func displayText(line string) string {
+	if trimSpace {
+		line = strings.TrimSpace(line)
+	}
+	if unescapeFlag {
+		line = unescapeString(line)
+	}
+	return line
+}
+
+// 搜索的大小写模式（--search-case）
+const (
+	caseIgnore    = "ignore"    // 不区分大小写（默认）
+	caseSmart     = "smart"     // 模式中包含大写字母时区分大小写，否则不区分
+	caseSensitive = "sensitive" // 区分大小写
+)
+
+// caseModes 分页器中按 i 键依次切换的大小写模式
+var caseModes = []string{caseIgnore, caseSmart, caseSensitive}
+
+// caseModeNames 大小写模式的显示名称
+var caseModeNames = map[string]string{
+	caseIgnore:    "不区分大小写",
+	caseSmart:     "智能大小写",
+	caseSensitive: "区分大小写",
+}
+
+// parseCaseMode 检查 --search-case 参数
+func parseCaseMode(mode string) error {
+	if _, ok := caseModeNames[mode]; !ok {
+		return fmt.Errorf("--search-case 的值必须是 ignore、smart 或 sensitive: %s", mode)
+	}
+	return nil
+}
+
+// nextCaseMode 返回切换后的大小写模式
+func nextCaseMode(mode string) string {
+	for i, m := range caseModes {
+		if m == mode {
+			return caseModes[(i+1)%len(caseModes)]
+		}
+	}
+	return caseIgnore
+}
+
+// matchCase 按当前大小写模式判断模式是否需要区分大小写
+func matchCase(pattern string) bool {
+	switch searchCase {
+	case caseSensitive:
+		return true
+	case caseSmart:
+		for _, r := range pattern {
+			if unicode.IsUpper(r) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findMatches 返回行中所有不重叠的匹配区间（按当前的大小写模式和整词设置）
+// 不区分大小写时逐个字符按 Unicode 大小写折叠比较，区间始终是原始行中的字节位置，
+// 不会因为大小写转换改变字节长度（如 "İ"、"ẞ"）而错位
+func findMatches(line, pattern string) [][2]int {
+	if pattern == "" {
+		return nil
+	}
+
+	sensitive := matchCase(pattern)
+	var ranges [][2]int
+	for i := 0; i < len(line); {
+		end, ok := 0, false
+		if sensitive {
+			idx := strings.Index(line[i:], pattern)
+			if idx == -1 {
+				break
+			}
+			i += idx
+			end, ok = i+len(pattern), true
+		} else {
+			end, ok = matchFoldAt(line, i, pattern)
+		}
+		if ok && (!searchWord || wholeWordAt(line, i, end, pattern)) {
+			ranges = append(ranges, [2]int{i, end})
+			i = end
+			continue
+		}
+		// 不是整词时从下一个字符继续，"id=12" 仍能匹配 "id=123 id=12" 中后面的一处
+		_, size := utf8.DecodeRuneInString(line[i:])
+		i += size
+	}
+	return ranges
+}
+
+// matchFoldAt 检查 line 从字节位置 i 开始是否与 pattern 大小写折叠后相等，返回匹配结束的位置
+func matchFoldAt(line string, i int, pattern string) (int, bool) {
+	for _, pr := range pattern {
+		if i >= len(line) {
+			return 0, false
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if !equalFoldRune(r, pr) {
+			return 0, false
+		}
+		i += size
+	}
+	return i, true
+}
+
+// equalFoldRune 两个字符在 Unicode 简单大小写折叠下是否相等
+func equalFoldRune(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	if a < utf8.RuneSelf && b < utf8.RuneSelf {
+		return 'A' <= a && a <= 'Z' && a+'a'-'A' == b || 'A' <= b && b <= 'Z' && b+'a'-'A' == a
+	}
+	for f := unicode.SimpleFold(a); f != a; f = unicode.SimpleFold(f) {
+		if f == b {
+			return true
+		}
+	}
+	return false
+}
+
+// searchInFile 在文件中搜索，返回所有匹配（同一行中的多处匹配分别列出）
+func searchInFile(filePath string, totalLines int, query searchQuery) []searchMatch {
+	var matches []searchMatch
+
+	if query.text == "" {
+		return matches
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return matches
+	}
+	defer file.Close()
+
+	scanner := newLogScanner(file)
+	for i := 0; i < totalLines && scanner.Scan(); i++ {
+		// 应用与显示相同的处理，匹配位置与显示内容一致
+		line := displayText(scanner.Text())
+		for _, r := range query.find(line) {
+			matches = append(matches, searchMatch{line: i, start: r[0], end: r[1]})
+		}
+	}
+
+	return matches
+}
+
+// revealColumn 不换行模式下调整水平滚动位置，使第 lineNum 行中 [start, end) 的内容可见
+// 返回新的滚动列数
+func revealColumn(src *lineReader, m searchMatch, hOffset, availableWidth int) int {
+	raw, err := src.line(m.line)
+	if err != nil {
+		return hOffset
+	}
+	line := displayText(raw)
+	if m.end > len(line) {
+		return hOffset
+	}
+	startCol := displayWidth(line[:m.start])
+	endCol := displayWidth(line[:m.end])
+	// 两侧各留一列给滚动标记
+	if startCol >= hOffset+1 && endCol <= hOffset+availableWidth-1 {
+		return hOffset
+	}
+	if endCol <= availableWidth-1 {
+		return 0
+	}
+	offset := startCol - availableWidth/4
+	if offset < 0 {
+		offset = 0
+	}
+	return offset
+}
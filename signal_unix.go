@@ -13,3 +13,27 @@ import (
 func setupSignalHandler(sigChan chan os.Signal) {
 	signal.Notify(sigChan, syscall.SIGWINCH)
 }
+
+// setupExitSignalHandler 监听终止信号，以便退出前恢复终端
+func setupExitSignalHandler(sigChan chan os.Signal) {
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT)
+}
+
+// setupSuspendSignalHandler 监听挂起（SIGTSTP）和恢复（SIGCONT）信号
+func setupSuspendSignalHandler(sigChan chan os.Signal) {
+	signal.Notify(sigChan, syscall.SIGTSTP, syscall.SIGCONT)
+}
+
+// isSuspendSignal 判断是否为挂起信号
+func isSuspendSignal(sig os.Signal) bool {
+	return sig == syscall.SIGTSTP
+}
+
+// suspendProcess 挂起当前进程（等同于 Ctrl+Z），收到 SIGCONT 后返回
+// 调用前需要先恢复终端状态
+func suspendProcess(sigChan chan os.Signal) {
+	// 暂时恢复 SIGTSTP 的默认行为，让进程真正停止
+	signal.Reset(syscall.SIGTSTP)
+	syscall.Kill(syscall.Getpid(), syscall.SIGTSTP)
+	signal.Notify(sigChan, syscall.SIGTSTP)
+}
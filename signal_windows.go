@@ -5,6 +5,7 @@ package main
 
 import (
 	"os"
+	"os/signal"
 )
 
 // setupSignalHandler 设置信号处理器（Windows）
@@ -13,3 +14,21 @@ func setupSignalHandler(sigChan chan os.Signal) {
 	// Windows 不支持窗口大小变化信号
 	// 这里保持空实现，不监听任何信号
 }
+
+// setupExitSignalHandler 监听终止信号，以便退出前恢复终端
+func setupExitSignalHandler(sigChan chan os.Signal) {
+	signal.Notify(sigChan, os.Interrupt)
+}
+
+// setupSuspendSignalHandler Windows 不支持作业控制，这里是空实现
+func setupSuspendSignalHandler(sigChan chan os.Signal) {
+}
+
+// isSuspendSignal Windows 没有挂起信号
+func isSuspendSignal(sig os.Signal) bool {
+	return false
+}
+
+// suspendProcess Windows 不支持挂起进程，这里是空实现
+func suspendProcess(sigChan chan os.Signal) {
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 统计报告的参数
+const (
+	statsTopN       = 5  // 服务、主机、最长的行、错误高峰各列出的条数
+	burstMinErrors  = 3  // 错误高峰中每分钟至少的错误数
+	burstAvgFactor  = 3  // 错误高峰中每分钟的错误数至少是整个时间范围内平均值的倍数
+	statsPreviewLen = 60 // 最长的行显示的开头部分的宽度
+)
+
+// statsFields 结构化日志中统计出现次数的字段：显示名称和依次查找的字段名
+var statsFields = []struct {
+	name string
+	keys []string
+}{
+	{"service", []string{"service", "service_name", "app", "component"}},
+	{"host", []string{"host", "hostname", "node"}},
+}
+
+// timeFields JSON 行中表示时间的字段，按顺序查找
+var timeFields = []string{"timestamp", "time", "ts", "@timestamp"}
+
+// timeLayouts 解析时间戳时依次尝试的格式，日期中的 / 和 . 先替换为 -
+// 解析时秒后面的小数部分（. 或 , 分隔）总是可以省略
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"15:04:05",
+}
+
+// syslogTime syslog 格式的行首时间戳（没有年份）
+var syslogTime = regexp.MustCompile(`^[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}`)
+
+// lineTime 返回行中的时间戳
+// JSON 行使用 timestamp/time/ts 字段（可以是 Unix 秒或毫秒），其他行使用第一个时间戳；
+// 只有时刻或 syslog 格式的时间戳没有日期，年份为 0
+func lineTime(line string) (time.Time, bool) {
+	if strings.HasPrefix(strings.TrimSpace(line), "{") {
+		for _, name := range timeFields {
+			if start, end, ok := fieldRange(line, name); ok {
+				return parseTime(line[start:end])
+			}
+		}
+		return time.Time{}, false
+	}
+	if s := syslogTime.FindString(line); s != "" {
+		t, err := time.Parse(time.Stamp, s)
+		return t, err == nil
+	}
+	if s := timestampPattern.FindString(line); s != "" {
+		return parseTime(s)
+	}
+	return time.Time{}, false
+}
+
+// parseTime 按 timeLayouts 解析时间戳，数字按 Unix 时间处理（大于 1e12 时为毫秒）
+func parseTime(s string) (time.Time, bool) {
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		if n > 1e12 {
+			n /= 1000
+		}
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+	}
+	if len(s) >= 10 && (s[4] == '/' || s[4] == '.') && s[7] == s[4] {
+		s = s[:4] + "-" + s[5:7] + "-" + s[8:]
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// formatTime 显示时间戳，没有日期时只显示时刻
+func formatTime(t time.Time) string {
+	if t.Year() == 0 {
+		return t.Format("15:04:05")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// valueCount 字段的一个值及其出现次数
+type valueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// fieldStats 一个字段的不同值及出现次数最多的值
+type fieldStats struct {
+	Field    string       `json:"field"`
+	Distinct int          `json:"distinct"`
+	Top      []valueCount `json:"top"`
+}
+
+// longLine 一条较长的行
+type longLine struct {
+	Line  int    `json:"line"` // 行号（1 基）
+	Bytes int    `json:"bytes"`
+	text  string // 行的开头部分，只在可读的报告中显示
+}
+
+// errorBurst 错误明显多于平均值的一段连续时间（按分钟）
+type errorBurst struct {
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Errors int    `json:"errors"`
+	start  time.Time
+	end    time.Time
+}
+
+// logStats lg stats 的统计结果
+type logStats struct {
+	Name       string         `json:"name"`
+	Bytes      int64          `json:"bytes"`
+	Lines      int            `json:"lines"`
+	Records    int            `json:"records"`
+	JSONLines  int            `json:"json_lines"`
+	PlainLines int            `json:"plain_lines"`
+	BlankLines int            `json:"blank_lines"`
+	FirstTime  string         `json:"first_time,omitempty"`
+	LastTime   string         `json:"last_time,omitempty"`
+	Seconds    float64        `json:"duration_seconds"`
+	Levels     map[string]int `json:"levels"` // 每条记录首行的等级，没有等级的记录计入 none
+	Fields     []fieldStats   `json:"fields"`
+	Longest    []longLine     `json:"longest_lines"`
+	Bursts     []errorBurst   `json:"error_bursts"`
+	first      time.Time
+	last       time.Time
+}
+
+// countingReader 统计读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read 读取底层输入并累加读到的字节数
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// collectStats 读取整个输入并统计
+// 等级、时间戳和错误高峰按记录计算（使用记录首行），续行如堆栈只计入行数和格式
+func collectStats(name string, reader io.Reader) (*logStats, error) {
+	splitter, err := newRecordSplitter()
+	if err != nil {
+		return nil, err
+	}
+	counter := &countingReader{r: reader}
+	scanner := newLogScanner(counter)
+
+	stats := &logStats{Name: name, Levels: map[string]int{}, Fields: []fieldStats{}, Longest: []longLine{}}
+	fieldCounts := make([]map[string]int, len(statsFields))
+	for i := range fieldCounts {
+		fieldCounts[i] = map[string]int{}
+	}
+	var points []timePoint
+
+	for ; scanner.Scan(); stats.Lines++ {
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			stats.BlankLines++
+		// 只把对象和数组算作 JSON，"42"、"true" 这样的行仍是文本
+		case strings.HasPrefix(strings.TrimSpace(line), "{") && isJSONLine(line),
+			strings.HasPrefix(strings.TrimSpace(line), "[") && isJSONLine(line):
+			stats.JSONLines++
+		default:
+			stats.PlainLines++
+		}
+		stats.addLongLine(stats.Lines+1, line)
+
+		if !splitter.isStart(line) {
+			continue
+		}
+		stats.Records++
+		level := lineLevel(line)
+		if level == "" {
+			level = "none"
+		}
+		stats.Levels[level]++
+		for i, f := range statsFields {
+			for _, key := range f.keys {
+				if start, end, ok := fieldRange(line, key); ok && start < end {
+					fieldCounts[i][line[start:end]]++
+					break
+				}
+			}
+		}
+
+		if p, ok := newTimePoint(line, stats.Lines); ok {
+			points = append(points, p)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// 时间范围和错误高峰与柱状图一样，有带日期的时间戳时不计只有时刻的时间戳
+	points = dropUndated(points)
+	errorMinutes := map[int64]int{}
+	for i, p := range points {
+		if i == 0 || p.t.Before(stats.first) {
+			stats.first = p.t
+		}
+		if i == 0 || p.t.After(stats.last) {
+			stats.last = p.t
+		}
+		if histogramLevels[p.level] == "error" {
+			errorMinutes[p.t.Unix()/60]++
+		}
+	}
+
+	stats.Bytes = counter.n
+	if len(points) > 0 {
+		stats.FirstTime = stats.first.Format(time.RFC3339Nano)
+		stats.LastTime = stats.last.Format(time.RFC3339Nano)
+		stats.Seconds = stats.last.Sub(stats.first).Seconds()
+	}
+	for i, f := range statsFields {
+		if len(fieldCounts[i]) > 0 {
+			stats.Fields = append(stats.Fields, topValues(f.name, fieldCounts[i]))
+		}
+	}
+	stats.Bursts = findBursts(errorMinutes, stats.first, stats.last)
+	return stats, nil
+}
+
+// addLongLine 记录最长的 statsTopN 行
+func (s *logStats) addLongLine(lineNum int, line string) {
+	if len(s.Longest) == statsTopN && len(line) <= s.Longest[statsTopN-1].Bytes {
+		return
+	}
+	l := longLine{Line: lineNum, Bytes: len(line), text: truncateWidth(line, statsPreviewLen)}
+	i := sort.Search(len(s.Longest), func(i int) bool { return s.Longest[i].Bytes < l.Bytes })
+	s.Longest = append(s.Longest, longLine{})
+	copy(s.Longest[i+1:], s.Longest[i:])
+	s.Longest[i] = l
+	if len(s.Longest) > statsTopN {
+		s.Longest = s.Longest[:statsTopN]
+	}
+}
+
+// topValues 返回出现次数最多的 statsTopN 个值，次数相同时按值排序
+func topValues(field string, counts map[string]int) fieldStats {
+	values := make([]valueCount, 0, len(counts))
+	for v, n := range counts {
+		values = append(values, valueCount{Value: v, Count: n})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	if len(values) > statsTopN {
+		values = values[:statsTopN]
+	}
+	return fieldStats{Field: field, Distinct: len(counts), Top: values}
+}
+
+// findBursts 找出错误高峰：每分钟的错误数都不少于 burstMinErrors，
+// 且不少于整个时间范围内平均值的 burstAvgFactor 倍的连续分钟，按错误数从多到少返回前 statsTopN 个
+func findBursts(errorMinutes map[int64]int, first, last time.Time) []errorBurst {
+	total := 0
+	minutes := make([]int64, 0, len(errorMinutes))
+	for m, n := range errorMinutes {
+		minutes = append(minutes, m)
+		total += n
+	}
+	sort.Slice(minutes, func(i, j int) bool { return minutes[i] < minutes[j] })
+
+	span := last.Unix()/60 - first.Unix()/60 + 1
+	threshold := math.Max(burstMinErrors, burstAvgFactor*float64(total)/float64(span))
+
+	bursts := []errorBurst{}
+	for i := 0; i < len(minutes); {
+		if float64(errorMinutes[minutes[i]]) < threshold {
+			i++
+			continue
+		}
+		j, errors := i, 0
+		for j < len(minutes) && minutes[j] == minutes[i]+int64(j-i) && float64(errorMinutes[minutes[j]]) >= threshold {
+			errors += errorMinutes[minutes[j]]
+			j++
+		}
+		start := time.Unix(minutes[i]*60, 0).In(first.Location())
+		end := time.Unix(minutes[j-1]*60+59, 0).In(first.Location())
+		bursts = append(bursts, errorBurst{
+			Start: start.Format(time.RFC3339), End: end.Format(time.RFC3339), Errors: errors,
+			start: start, end: end,
+		})
+		i = j
+	}
+	sort.SliceStable(bursts, func(i, j int) bool { return bursts[i].Errors > bursts[j].Errors })
+	if len(bursts) > statsTopN {
+		bursts = bursts[:statsTopN]
+	}
+	return bursts
+}
+
+// printStats 输出统计报告（lg stats），jsonOutput 时输出 JSON
+func printStats(name string, reader io.Reader) error {
+	stats, err := collectStats(name, reader)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	title := func(s string) {
+		fmt.Printf("\n%s\n", paint(activeTheme.Title, s))
+	}
+	row := func(label, value string) {
+		fmt.Printf("  %s%s\n", padWidth(label, 12), value)
+	}
+	percent := func(n, total int) string {
+		if total == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%5.1f%%", float64(n)*100/float64(total))
+	}
+
+	fmt.Println(paint(activeTheme.Title, stats.Name))
+	row("大小", fmt.Sprintf("%s（%d 字节）", formatBytes(stats.Bytes), stats.Bytes))
+	row("行数", fmt.Sprintf("%d（%d 条记录）", stats.Lines, stats.Records))
+	row("格式", fmt.Sprintf("JSON %d 行，纯文本 %d 行，空行 %d 行", stats.JSONLines, stats.PlainLines, stats.BlankLines))
+	if stats.FirstTime != "" {
+		span := stats.last.Sub(stats.first).Truncate(time.Second)
+		row("时间范围", fmt.Sprintf("%s – %s（%s）", formatTime(stats.first), formatTime(stats.last), span))
+	} else {
+		row("时间范围", paint(activeTheme.Muted, "没有时间戳"))
+	}
+
+	title("等级（按记录）")
+	for _, level := range []string{"error", "warn", "info", "debug", "none"} {
+		n, ok := stats.Levels[level]
+		if !ok {
+			continue
+		}
+		label := strings.ToUpper(level)
+		if level == "none" {
+			label = "无等级"
+		}
+		if code, _ := activeTheme.item(level); level != "none" && *code != "" {
+			label = paint(*code, padWidth(label, 8))
+		} else {
+			label = padWidth(label, 8)
+		}
+		fmt.Printf("  %s%8d  %s\n", label, n, percent(n, stats.Records))
+	}
+
+	for _, f := range stats.Fields {
+		title(fmt.Sprintf("%s（%d 个不同的值）", f.Field, f.Distinct))
+		for _, v := range f.Top {
+			fmt.Printf("  %s%8d  %s\n", padWidth(truncateWidth(v.Value, 30), 32), v.Count, percent(v.Count, stats.Records))
+		}
+	}
+
+	title("最长的行")
+	for _, l := range stats.Longest {
+		fmt.Printf("  %s %8d 字节  %s\n", paint(activeTheme.Gutter, fmt.Sprintf("%6d", l.Line)), l.Bytes, paint(activeTheme.Muted, l.text))
+	}
+
+	title("错误高峰")
+	if len(stats.Bursts) == 0 {
+		fmt.Printf("  %s\n", paint(activeTheme.Muted, "无"))
+	}
+	for _, b := range stats.Bursts {
+		fmt.Printf("  %s – %s  %s\n", formatTime(b.start), b.end.Format("15:04:05"), paint(activeTheme.Error, fmt.Sprintf("%d 条错误", b.Errors)))
+	}
+	return nil
+}
+
+// padWidth 在文本后补空格，使其显示宽度至少为 width
+func padWidth(s string, width int) string {
+	if w := displayWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s + " "
+}
+
+// formatBytes 以 KB、MB 等单位显示字节数
+func formatBytes(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	unit := ""
+	for _, u := range []string{"KB", "MB", "GB", "TB"} {
+		value /= 1024
+		unit = u
+		if value < 1024 {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f %s", value, unit)
+}
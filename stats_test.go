@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCollectStatsMixedTimestamps(t *testing.T) {
+	input := strings.Join([]string{
+		"10:00:00 ERROR a",
+		"Mar  3 10:01:00 host app: ERROR b",
+		"2025-01-01 10:05:00 INFO c",
+		"2025-01-01 10:07:30 ERROR d",
+	}, "\n") + "\n"
+	stats, err := collectStats("mixed.log", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.FirstTime != "2025-01-01T10:05:00Z" || stats.LastTime != "2025-01-01T10:07:30Z" {
+		t.Errorf("时间范围为 %s – %s，期望只统计带日期的时间戳", stats.FirstTime, stats.LastTime)
+	}
+	if stats.Seconds != 150 {
+		t.Errorf("duration_seconds = %v，期望 150", stats.Seconds)
+	}
+	for _, b := range stats.Bursts {
+		if !strings.HasPrefix(b.Start, "2025-") {
+			t.Errorf("错误高峰 %s 不在带日期的时间范围内", b.Start)
+		}
+	}
+	if stats.Levels["error"] != 3 {
+		t.Errorf("error 记录数为 %d，期望 3（时间戳不影响等级统计）", stats.Levels["error"])
+	}
+}
+
+func TestCollectStatsTimeOnly(t *testing.T) {
+	input := "10:00:00 INFO a\n10:02:00 ERROR b\n"
+	stats, err := collectStats("clock.log", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只有时刻时仍按时刻计算时间范围
+	if stats.Seconds != 120 {
+		t.Errorf("duration_seconds = %v，期望 120", stats.Seconds)
+	}
+}
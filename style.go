@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+)
+
+// styleSpan 表示字符串中需要着色的一段字节区间 [start, end)
+type styleSpan struct {
+	start int
+	end   int
+	code  string // ANSI 颜色代码（不含 \033[ 与 m）
+}
+
+// renderSpans 按区间为字符串着色
+// 区间可以重叠，后出现的区间优先级更高（例如搜索高亮覆盖 JSON 语法色）
+func renderSpans(s string, spans []styleSpan) string {
+	if len(spans) == 0 || s == "" {
+		return s
+	}
+
+	// 记录每个字节最终使用的区间下标，-1 表示不着色
+	owner := make([]int32, len(s))
+	for i := range owner {
+		owner[i] = -1
+	}
+	for i, sp := range spans {
+		start, end := sp.start, sp.end
+		if start < 0 {
+			start = 0
+		}
+		if end > len(s) {
+			end = len(s)
+		}
+		for b := start; b < end; b++ {
+			owner[b] = int32(i)
+		}
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s) + len(spans)*10)
+	current := ""
+	for i := 0; i < len(s); i++ {
+		code := ""
+		if owner[i] >= 0 {
+			code = spans[owner[i]].code
+		}
+		if code != current {
+			if current != "" {
+				sb.WriteString("\033[0m")
+			}
+			if code != "" {
+				sb.WriteString("\033[" + code + "m")
+			}
+			current = code
+		}
+		sb.WriteByte(s[i])
+	}
+	if current != "" {
+		sb.WriteString("\033[0m")
+	}
+	return sb.String()
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// 终端模式控制序列
+const (
+	escAltScreenEnter = "\033[?1049h"
+	escAltScreenLeave = "\033[?1049l"
+	// 备用屏幕中滚轮转换为方向键（不影响终端的文本选择）
+	escAltScrollOn  = "\033[?1007h"
+	escAltScrollOff = "\033[?1007l"
+	// 鼠标按键报告（SGR 格式），用于滚轮滚动
+	escMouseOn  = "\033[?1000h\033[?1006h"
+	escMouseOff = "\033[?1006l\033[?1000l"
+	// 括号粘贴模式：粘贴的内容前后带有标记，命令行中粘贴的换行不会被当作 Enter
+	escPasteOn  = "\033[?2004h"
+	escPasteOff = "\033[?2004l"
+)
+
+// terminalSession 交互模式对终端所做的修改（原始模式、备用屏幕）
+// 正常退出、panic、终止信号和挂起时都通过 restore 恢复终端
+type terminalSession struct {
+	mu        sync.Mutex
+	fd        int
+	oldState  *term.State
+	altScreen bool
+	mouse     bool
+	active    bool
+}
+
+// startTerminalSession 进入原始模式，并根据参数切换到备用屏幕、开启鼠标报告
+func startTerminalSession(altScreen, mouse bool) (*terminalSession, error) {
+	t := &terminalSession{fd: int(os.Stdin.Fd()), altScreen: altScreen, mouse: mouse}
+	if err := t.enter(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// enter 进入原始模式和备用屏幕（已进入时不做任何事）
+func (t *terminalSession) enter() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.active {
+		return nil
+	}
+
+	oldState, err := term.MakeRaw(t.fd)
+	if err != nil {
+		return fmt.Errorf("无法进入原始终端模式: %v", err)
+	}
+	t.oldState = oldState
+	fmt.Print(t.modesOn())
+	t.active = true
+	return nil
+}
+
+// modesOn 返回进入交互模式时需要开启的终端模式
+func (t *terminalSession) modesOn() string {
+	modes := ""
+	if t.altScreen {
+		modes += escAltScreenEnter
+		if !t.mouse {
+			modes += escAltScrollOn
+		}
+	}
+	if t.mouse {
+		modes += escMouseOn
+	}
+	return modes + escPasteOn
+}
+
+// modesOff 返回退出交互模式时需要关闭的终端模式（与 modesOn 顺序相反）
+func (t *terminalSession) modesOff() string {
+	modes := escPasteOff
+	if t.mouse {
+		modes += escMouseOff
+	}
+	if t.altScreen {
+		if !t.mouse {
+			modes += escAltScrollOff
+		}
+		modes += escAltScreenLeave
+	}
+	return modes
+}
+
+// reapply 进程被外部暂停后继续运行时，重新设置原始模式和备用屏幕
+// shell 在作业控制中可能已经修改了终端设置
+func (t *terminalSession) reapply() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.active {
+		return
+	}
+	term.MakeRaw(t.fd)
+	fmt.Print(t.modesOn())
+}
+
+// restore 离开备用屏幕并恢复原始终端状态，可以安全地重复调用
+func (t *terminalSession) restore() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.active {
+		return
+	}
+	fmt.Print(t.modesOff())
+	term.Restore(t.fd, t.oldState)
+	t.active = false
+}
+
+// inputEventKind 输入事件类型
+type inputEventKind int
+
+const (
+	eventKey    inputEventKind = iota // 按键输入
+	eventResize                       // 终端大小变化
+	eventResume                       // 挂起后恢复运行
+)
+
+// inputEvent 主循环处理的输入事件
+type inputEvent struct {
+	kind inputEventKind
+	key  keyEvent
+}
+
+// terminalInput 在后台读取标准输入
+// 主循环可以同时等待按键和信号事件（窗口大小变化、挂起恢复），所有按键读取都经过这里
+type terminalInput struct {
+	data    chan []byte
+	events  chan inputEvent
+	pending []byte // 已读取但尚未解码的字节
+}
+
+// keyInput 交互模式下的输入源；为 nil 时直接读取标准输入
+var keyInput *terminalInput
+
+// newTerminalInput 创建输入源并启动后台读取
+func newTerminalInput() *terminalInput {
+	in := &terminalInput{
+		data:   make(chan []byte, 16),
+		events: make(chan inputEvent, 4),
+	}
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if n > 0 {
+				chunk := make([]byte, n)
+				copy(chunk, buf[:n])
+				in.data <- chunk
+			}
+			if err != nil {
+				close(in.data)
+				return
+			}
+		}
+	}()
+	return in
+}
+
+// post 投递一个非按键事件，队列已满时丢弃（重复的重绘请求没有意义）
+func (in *terminalInput) post(kind inputEventKind) {
+	select {
+	case in.events <- inputEvent{kind: kind}:
+	default:
+	}
+}
+
+// next 等待下一个事件：解码后的按键或信号事件
+func (in *terminalInput) next() (inputEvent, error) {
+	for {
+		if len(in.pending) > 0 {
+			key, n, needMore := parseKey(in.pending, false)
+			if !needMore {
+				in.pending = in.pending[n:]
+				return inputEvent{kind: eventKey, key: key}, nil
+			}
+			// 序列不完整：等待后续字节，超时则按已有的字节解码（例如单独的 ESC）
+			select {
+			case chunk, ok := <-in.data:
+				if ok {
+					in.pending = append(in.pending, chunk...)
+					continue
+				}
+			case <-time.After(escTimeout):
+			}
+			key, n, _ = parseKey(in.pending, true)
+			in.pending = in.pending[n:]
+			return inputEvent{kind: eventKey, key: key}, nil
+		}
+
+		select {
+		case ev := <-in.events:
+			return ev, nil
+		case chunk, ok := <-in.data:
+			if !ok {
+				return inputEvent{}, io.EOF
+			}
+			in.pending = append(in.pending, chunk...)
+		}
+	}
+}
+
+// nextKey 等待下一个按键，忽略期间的信号事件
+func (in *terminalInput) nextKey() (keyEvent, error) {
+	for {
+		ev, err := in.next()
+		if err != nil {
+			return keyEvent{}, err
+		}
+		if ev.kind == eventKey {
+			return ev.key, nil
+		}
+	}
+}
+
+// waitForKey 等待用户按任意键
+func waitForKey() {
+	if keyInput != nil {
+		keyInput.nextKey()
+		return
+	}
+	buf := make([]byte, 1)
+	os.Stdin.Read(buf)
+}
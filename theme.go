@@ -0,0 +1,436 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// colorTheme 界面各元素使用的 ANSI 样式代码（不含 \033[ 与 m，空字符串表示不着色）
+type colorTheme struct {
+	Gutter       string   // 行号
+	Search       string   // 搜索高亮
+	CurrentMatch string   // 当前搜索匹配
+	Error        string   // ERROR / FATAL 等级
+	Warn         string   // WARN 等级
+	Info         string   // INFO 等级
+	Debug        string   // DEBUG / TRACE 等级
+	Status       string   // 底部状态栏
+	Title        string   // 格式化页面的标题
+	Muted        string   // 提示文字
+	Highlights   []string // 固定高亮（:hl）依次使用的颜色
+	JSON         jsonColorTheme
+}
+
+// highlightPalette 固定高亮的默认色板：浅色背景加黑色文字，深色和浅色终端上都清晰
+var highlightPalette = []string{
+	"30;48;5;210", // 红
+	"30;48;5;114", // 绿
+	"30;48;5;111", // 蓝
+	"30;48;5;183", // 紫
+	"30;48;5;80",  // 青
+	"30;48;5;215", // 橙
+}
+
+// themePresets 内置配色方案
+var themePresets = map[string]colorTheme{
+	// 深色背景（默认）
+	"dark": {
+		Gutter:       "36",
+		Search:       "43;30",
+		CurrentMatch: "1;30;48;5;208",
+		Error:        "1;31",
+		Warn:         "33",
+		Info:         "32",
+		Debug:        "90",
+		Status:       "38;5;252;48;5;238",
+		Title:        "32",
+		Muted:        "90",
+		Highlights:   highlightPalette,
+		JSON:         jsonThemeMap["default"],
+	},
+	// 浅色背景：使用较深的 256 色，避免黄色、青色等在白底上看不清
+	"light": {
+		Gutter:       "38;5;24",
+		Search:       "30;48;5;222",
+		CurrentMatch: "1;30;48;5;209",
+		Error:        "1;38;5;160",
+		Warn:         "38;5;130",
+		Info:         "38;5;28",
+		Debug:        "38;5;244",
+		Status:       "38;5;236;48;5;253",
+		Title:        "38;5;28",
+		Muted:        "38;5;244",
+		Highlights:   highlightPalette,
+		JSON: jsonColorTheme{
+			Key: "38;5;25", String: "38;5;28", Number: "38;5;130",
+			Bool: "38;5;127", Null: "38;5;244", Decoded: "38;5;244;3",
+		},
+	},
+}
+
+// noColorTheme 禁用颜色时使用：不输出任何颜色，分页器中搜索匹配和状态栏改用反显，固定高亮使用下划线
+var noColorTheme = colorTheme{
+	Search:       "7",
+	CurrentMatch: "1;7",
+	Status:       "7",
+	Highlights:   []string{"4"},
+}
+
+// activeTheme 当前使用的配色
+var activeTheme = themePresets["dark"]
+
+// colorOutput 是否输出颜色（由 --color 和 NO_COLOR 决定）
+var colorOutput = true
+
+// item 按名称返回配色方案中的元素，用于配置文件中定义的方案
+func (t *colorTheme) item(name string) (*string, bool) {
+	switch name {
+	case "gutter", "line-color":
+		return &t.Gutter, true
+	case "search", "search-color":
+		return &t.Search, true
+	case "current-match":
+		return &t.CurrentMatch, true
+	case "error":
+		return &t.Error, true
+	case "warn":
+		return &t.Warn, true
+	case "info":
+		return &t.Info, true
+	case "debug":
+		return &t.Debug, true
+	case "status":
+		return &t.Status, true
+	case "title":
+		return &t.Title, true
+	case "muted":
+		return &t.Muted, true
+	case "key":
+		return &t.JSON.Key, true
+	case "string":
+		return &t.JSON.String, true
+	case "number":
+		return &t.JSON.Number, true
+	case "bool":
+		return &t.JSON.Bool, true
+	case "null":
+		return &t.JSON.Null, true
+	case "decoded":
+		return &t.JSON.Decoded, true
+	}
+	return nil, false
+}
+
+// defaultThemeName 未指定配色方案时根据终端背景选择：
+// COLORFGBG 表示浅色背景（背景色为 7 或 15）时使用 light，否则使用 dark
+func defaultThemeName() string {
+	if v := os.Getenv("COLORFGBG"); v != "" {
+		parts := strings.Split(v, ";")
+		switch parts[len(parts)-1] {
+		case "7", "15":
+			return "light"
+		}
+	}
+	return "dark"
+}
+
+// loadTheme 按名称查找配色方案：先查配置文件中定义的方案，再查内置方案
+// 配置文件中的方案以 base 指定的内置方案（默认 dark）为基础，覆盖其中列出的元素
+func loadTheme(name string) (colorTheme, error) {
+	if name == "" {
+		name = defaultThemeName()
+	}
+	items, ok := configThemes[name]
+	if !ok {
+		preset, ok := themePresets[name]
+		if !ok {
+			return preset, fmt.Errorf("未知的配色方案: %s", name)
+		}
+		return preset, nil
+	}
+
+	baseName := items["base"]
+	if baseName == "" {
+		baseName = "dark"
+	}
+	t, ok := themePresets[baseName]
+	if !ok {
+		return t, fmt.Errorf("配色方案 %s 的 base 不是内置方案: %s", name, baseName)
+	}
+	for item, value := range items {
+		if item == "base" {
+			continue
+		}
+		if item == "highlights" {
+			// 以逗号分隔的颜色列表
+			t.Highlights = nil
+			for _, spec := range strings.Split(value, ",") {
+				code, err := parseStyle(spec)
+				if err != nil {
+					return t, fmt.Errorf("配色方案 %s 的 highlights: %v", name, err)
+				}
+				t.Highlights = append(t.Highlights, code)
+			}
+			continue
+		}
+		field, ok := t.item(item)
+		if !ok {
+			return t, fmt.Errorf("配色方案 %s 中未知的配色项: %s", name, item)
+		}
+		code, err := parseStyle(value)
+		if err != nil {
+			return t, fmt.Errorf("配色方案 %s 的 %s: %v", name, item, err)
+		}
+		*field = code
+	}
+	return t, nil
+}
+
+// colorEnabled 根据 --color 参数判断是否输出颜色
+// auto 时只在标准输出是终端且未设置 NO_COLOR 环境变量时输出颜色
+func colorEnabled(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto", "":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		return term.IsTerminal(int(os.Stdout.Fd())), nil
+	}
+	return false, fmt.Errorf("--color 的值必须是 auto、always 或 never: %s", mode)
+}
+
+// 颜色名称对应的前景色代码（背景色加 10）
+var colorNames = map[string]int{
+	"black": 30, "red": 31, "green": 32, "yellow": 33,
+	"blue": 34, "magenta": 35, "cyan": 36, "white": 37,
+	"gray": 90, "grey": 90,
+	"bright-black": 90, "bright-red": 91, "bright-green": 92, "bright-yellow": 93,
+	"bright-blue": 94, "bright-magenta": 95, "bright-cyan": 96, "bright-white": 97,
+}
+
+// 样式属性对应的代码
+var styleAttrs = map[string]string{
+	"bold": "1", "dim": "2", "italic": "3", "underline": "4", "ul": "4",
+	"blink": "5", "reverse": "7", "strike": "9",
+}
+
+// parseStyle 将样式描述转换为 ANSI 代码
+// 描述由空格分隔的属性和颜色组成，第一个颜色为前景色，第二个（或 on 之后的）为背景色，例如
+// "bold red"、"#ffaf00"、"black on #ffd75f"、"color208 color236"。
+// 颜色可以是名称、#rrggbb / #rgb 或 color0..color255（256 色）；
+// 只由数字和分号组成的描述视为原始 ANSI 代码（如 "33"、"43;30"），与旧版本兼容
+func parseStyle(spec string) (string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "none" {
+		return "", nil
+	}
+	if strings.Trim(spec, "0123456789;") == "" {
+		return spec, nil
+	}
+
+	var codes []string
+	colors := 0
+	forceBg := false
+	for _, token := range strings.Fields(strings.ToLower(spec)) {
+		if code, ok := styleAttrs[token]; ok {
+			codes = append(codes, code)
+			continue
+		}
+		if token == "on" {
+			forceBg = true
+			continue
+		}
+		if colors > 1 {
+			return "", fmt.Errorf("颜色过多: %s", spec)
+		}
+		bg := forceBg || colors > 0
+		code, err := colorCode(token, bg)
+		if err != nil {
+			return "", err
+		}
+		codes = append(codes, code)
+		colors++
+		forceBg = false
+	}
+	return strings.Join(codes, ";"), nil
+}
+
+// colorCode 将单个颜色转换为前景色或背景色代码
+func colorCode(token string, bg bool) (string, error) {
+	offset := 0
+	if bg {
+		offset = 10
+	}
+	if code, ok := colorNames[token]; ok {
+		return strconv.Itoa(code + offset), nil
+	}
+	if token == "default" {
+		return strconv.Itoa(39 + offset), nil
+	}
+
+	prefix := "38"
+	if bg {
+		prefix = "48"
+	}
+	if n, ok := strings.CutPrefix(token, "color"); ok {
+		if v, err := strconv.Atoi(n); err == nil && v >= 0 && v <= 255 {
+			return prefix + ";5;" + n, nil
+		}
+	}
+	if hex, ok := strings.CutPrefix(token, "#"); ok {
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) == 6 && isHexDigits(hex) {
+			r, g, b := hexValue(hex[0:2]), hexValue(hex[2:4]), hexValue(hex[4:6])
+			if !trueColorSupported() {
+				return prefix + ";5;" + strconv.Itoa(nearest256(r, g, b)), nil
+			}
+			return fmt.Sprintf("%s;2;%d;%d;%d", prefix, r, g, b), nil
+		}
+	}
+	return "", fmt.Errorf("未知的颜色: %s（可用颜色名称、#rrggbb 或 color0..color255）", token)
+}
+
+// trueColorSupported 终端是否声明支持 24 位颜色（COLORTERM=truecolor 或 24bit）
+func trueColorSupported() bool {
+	v := os.Getenv("COLORTERM")
+	return v == "truecolor" || v == "24bit"
+}
+
+// nearest256 返回与 RGB 颜色最接近的 256 色编号（6x6x6 色块或灰阶）
+func nearest256(r, g, b int) int {
+	level := func(v int) int {
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return (v - 35) / 40
+	}
+	levels := [6]int{0, 95, 135, 175, 215, 255}
+	ri, gi, bi := level(r), level(g), level(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDist := sq(levels[ri]-r) + sq(levels[gi]-g) + sq(levels[bi]-b)
+
+	avg := (r + g + b) / 3
+	gray := (avg - 3) / 10
+	if gray < 0 {
+		gray = 0
+	}
+	if gray > 23 {
+		gray = 23
+	}
+	gv := 8 + gray*10
+	if sq(gv-r)+sq(gv-g)+sq(gv-b) < cubeDist {
+		return 232 + gray
+	}
+	return cube
+}
+
+// sq 返回 v 的平方，用于计算颜色之间的距离
+func sq(v int) int {
+	return v * v
+}
+
+// paint 使用样式代码包裹文本，代码为空时原样返回（禁用颜色时不输出任何转义序列）
+func paint(code, text string) string {
+	if code == "" {
+		return text
+	}
+	return "\033[" + code + "m" + text + "\033[0m"
+}
+
+// levelWords 日志等级关键字对应的配色项
+var levelWords = map[string]string{
+	"FATAL": "error", "PANIC": "error", "CRITICAL": "error", "ERROR": "error", "ERR": "error",
+	"WARNING": "warn", "WARN": "warn",
+	"INFO":  "info",
+	"DEBUG": "debug", "TRACE": "debug",
+}
+
+// levelSpan 返回日志等级关键字的着色区间，不是等级关键字或该等级不着色时返回 false
+func levelSpan(word string, start, end int) (styleSpan, bool) {
+	name, ok := levelWords[word]
+	if !ok {
+		return styleSpan{}, false
+	}
+	code, _ := activeTheme.item(name)
+	if *code == "" {
+		return styleSpan{}, false
+	}
+	return styleSpan{start: start, end: end, code: *code}, true
+}
+
+// levelSpans 返回行中日志等级的着色区间
+func levelSpans(line string) []styleSpan {
+	word, start, end := findLevel(line)
+	if word == "" {
+		return nil
+	}
+	if span, ok := levelSpan(word, start, end); ok {
+		return []styleSpan{span}
+	}
+	return nil
+}
+
+// lineLevel 返回行的日志等级（error、warn、info、debug），没有等级关键字时返回空字符串
+func lineLevel(line string) string {
+	word, _, _ := findLevel(line)
+	return levelWords[word]
+}
+
+// findLevel 查找行中的日志等级关键字，返回大写的关键字及其字节区间
+// 只查找第一个等级关键字：大写的独立单词（如 " ERROR "），
+// 或 JSON 中 "level"/"severity" 字段的值（不区分大小写）
+func findLevel(line string) (string, int, int) {
+	for _, key := range []string{`"level":"`, `"severity":"`, `"level": "`, `"severity": "`} {
+		if i := strings.Index(line, key); i >= 0 {
+			start := i + len(key)
+			end := strings.IndexByte(line[start:], '"')
+			if end > 0 {
+				word := strings.ToUpper(line[start : start+end])
+				if _, ok := levelWords[word]; ok {
+					return word, start, start + end
+				}
+				return "", 0, 0
+			}
+		}
+	}
+
+	for i := 0; i < len(line); {
+		if !isUpperASCII(line[i]) || (i > 0 && isWordByte(line[i-1])) {
+			i++
+			continue
+		}
+		end := i
+		for end < len(line) && isUpperASCII(line[end]) {
+			end++
+		}
+		if end == len(line) || !isWordByte(line[end]) {
+			if _, ok := levelWords[line[i:end]]; ok {
+				return line[i:end], i, end
+			}
+		}
+		i = end
+	}
+	return "", 0, 0
+}
+
+func isUpperASCII(ch byte) bool {
+	return ch >= 'A' && ch <= 'Z'
+}
+
+// isWordByte 是否为单词字符（字母、数字、下划线）
+func isWordByte(ch byte) bool {
+	return ch == '_' || (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
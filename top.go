@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// fieldValue 字段的一个值，以及该值出现的记录
+type fieldValue struct {
+	value string
+	lines []int // 包含该值的记录首行的行号（升序）
+}
+
+// fieldBreakdown 字段各个值的出现次数（:top 命令）
+type fieldBreakdown struct {
+	field   string
+	values  []*fieldValue // 按出现次数从多到少排序
+	total   int           // 统计的记录数
+	missing int           // 没有该字段的记录数
+}
+
+// topFieldValues 统计 keep 为真的记录中字段的各个值，按记录首行的显示内容查找字段
+func topFieldValues(filePath string, records recordIndex, field string, keep func(line int) bool) (*fieldBreakdown, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result := &fieldBreakdown{field: field}
+	byValue := map[string]*fieldValue{}
+	scanner := newLogScanner(file)
+	for i := 0; i < records.total && scanner.Scan(); i++ {
+		if !records.isStart(i) || !keep(i) {
+			continue
+		}
+		result.total++
+		line := displayText(scanner.Text())
+		start, end, ok := fieldRange(line, field)
+		if !ok {
+			result.missing++
+			continue
+		}
+		value := line[start:end]
+		v := byValue[value]
+		if v == nil {
+			v = &fieldValue{value: value}
+			byValue[value] = v
+			result.values = append(result.values, v)
+		}
+		v.lines = append(v.lines, i)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result.values, func(i, j int) bool {
+		a, b := result.values[i], result.values[j]
+		if len(a.lines) != len(b.lines) {
+			return len(a.lines) > len(b.lines)
+		}
+		return a.value < b.value
+	})
+	return result, nil
+}
+
+// showTopMenu 显示字段各个值的排行（:top 命令），返回选中的值，按 q/Esc 退出时返回 nil
+func showTopMenu(b *fieldBreakdown, width, height int) *fieldValue {
+	// 值一列的宽度：最长的值，但给次数和百分比留出位置
+	valueWidth := 0
+	for _, v := range b.values {
+		if w := displayWidth(v.value); w > valueWidth {
+			valueWidth = w
+		}
+	}
+	if limit := width - 30; valueWidth > limit {
+		valueWidth = limit
+	}
+	if valueWidth < 8 {
+		valueWidth = 8
+	}
+
+	selected, top := 0, 0
+	for {
+		listHeight := height - 5
+		if listHeight < 1 {
+			listHeight = 1
+		}
+		if selected < top {
+			top = selected
+		}
+		if selected >= top+listHeight {
+			top = selected - listHeight + 1
+		}
+
+		title := fmt.Sprintf("=== %s（%d 个不同的值，%d 条记录", b.field, len(b.values), b.total)
+		if b.missing > 0 {
+			title += fmt.Sprintf("，%d 条没有该字段", b.missing)
+		}
+		fmt.Print("\033[2J\033[H")
+		fmt.Print(paint(activeTheme.Title, truncateWidth(title+"）===", width-1)) + "\r\n\r\n")
+		for i := top; i < len(b.values) && i < top+listHeight; i++ {
+			v := b.values[i]
+			marker := "  "
+			if i == selected {
+				marker = "> "
+			}
+			text := fmt.Sprintf("%s%4d  %s %8d %6.1f%%", marker, i+1, padWidth(truncateWidth(v.value, valueWidth), valueWidth),
+				len(v.lines), float64(len(v.lines))*100/float64(b.total))
+			if i == selected {
+				text = paint(activeTheme.Search, text)
+			}
+			fmt.Print(text + "\r\n")
+		}
+		fmt.Printf("\033[%d;1H%s", height, paint(activeTheme.Muted, "j/k 选择  Enter 只显示该值的记录  q/Esc 返回"))
+
+		key, err := keyInput.nextKey()
+		if err != nil {
+			return nil
+		}
+		switch key.name() {
+		case "j", "down":
+			if selected < len(b.values)-1 {
+				selected++
+			}
+		case "k", "up":
+			if selected > 0 {
+				selected--
+			}
+		case "ctrl+f", "space", "pgdn":
+			selected += listHeight
+			if selected > len(b.values)-1 {
+				selected = len(b.values) - 1
+			}
+		case "ctrl+b", "b", "pgup":
+			selected -= listHeight
+			if selected < 0 {
+				selected = 0
+			}
+		case "g", "home":
+			selected = 0
+		case "G", "end":
+			selected = len(b.values) - 1
+		case "enter":
+			return b.values[selected]
+		case "q", "esc":
+			return nil
+		}
+	}
+}
+
+// topFilterLabel 返回选中字段值后的过滤描述，已有过滤时在其后追加
+func topFilterLabel(current, field, value string) string {
+	label := field + "=" + value
+	if current != "" {
+		label = current + " & " + label
+	}
+	return label
+}
+
+// parseTopField 解析 :top 命令的字段路径，允许以 . 开头（与 :p 一致）
+func parseTopField(arg string) (string, error) {
+	field := strings.TrimPrefix(strings.TrimSpace(arg), ".")
+	if !isFieldName(field) {
+		return "", fmt.Errorf("用法: :top <字段>（如 :top service、:top error.code）")
+	}
+	return field, nil
+}
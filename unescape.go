@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// escapeSet 启用的转义符类别
+type escapeSet struct {
+	basic   bool // \n \t \r \\ \" \'
+	json    bool // \b \f \/
+	unicode bool // \uXXXX（含代理对）、\UXXXXXXXX
+	hex     bool // \xNN
+	octal   bool // \NNN
+}
+
+// defaultEscapeSpec 默认启用的转义符类别
+const defaultEscapeSpec = "basic,json,unicode,hex"
+
+// escapeConfig 当前使用的转义符类别（由 --escapes 解析得到）
+var escapeConfig = escapeSet{basic: true, json: true, unicode: true, hex: true}
+
+// parseEscapeSet 解析以逗号分隔的转义符类别列表
+// 可选值：basic、json、unicode、hex、octal，以及 all
+func parseEscapeSet(spec string) (escapeSet, error) {
+	var set escapeSet
+	for _, name := range strings.Split(spec, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "basic":
+			set.basic = true
+		case "json":
+			set.json = true
+		case "unicode":
+			set.unicode = true
+		case "hex":
+			set.hex = true
+		case "octal":
+			set.octal = true
+		case "all":
+			set = escapeSet{basic: true, json: true, unicode: true, hex: true, octal: true}
+		default:
+			return set, fmt.Errorf("未知的转义符类别: %s", name)
+		}
+	}
+	return set, nil
+}
+
+// decodeEscapes 使用状态机解码字符串中的转义符
+// 逐个字符扫描，\\ 会被整体消费，因此 \\n 会正确地得到反斜杠加字母 n；
+// 无法识别或不完整的转义序列原样保留。
+// oneLine 为 true 时 \n 替换为空格、\r 被删除；quotedOnly 为 true 时只解码双引号字符串内部的转义符
+func decodeEscapes(s string, set escapeSet, oneLine, quotedOnly bool) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+	inQuote := false
+	// pending 暂存 \xNN / \NNN 解码出的原始字节，连续的字节一起按 UTF-8 解码
+	var pending []byte
+	flush := func() {
+		for b := pending; len(b) > 0; {
+			r, size := utf8.DecodeRune(b)
+			if r == utf8.RuneError && size == 1 {
+				// 不是合法的 UTF-8 的字节替换为 U+FFFD，前后合法的字符照常输出
+				sb.WriteRune(utf8.RuneError)
+			} else {
+				sb.Write(b[:size])
+			}
+			b = b[size:]
+		}
+		pending = pending[:0]
+	}
+
+	for i := 0; i < len(s); {
+		ch := s[i]
+		if ch != '\\' || i+1 >= len(s) || (quotedOnly && !inQuote) {
+			flush()
+			if ch == '"' {
+				inQuote = !inQuote
+			}
+			if ch == '\\' && i+1 < len(s) {
+				// 引号外的转义序列原样保留（连同被转义的字符）
+				sb.WriteString(s[i : i+2])
+				i += 2
+				continue
+			}
+			sb.WriteByte(ch)
+			i++
+			continue
+		}
+
+		next := s[i+1]
+
+		// 字节类转义：\xNN 和 \NNN
+		if set.hex && next == 'x' && i+3 < len(s) && isHexDigits(s[i+2:i+4]) {
+			pending = append(pending, byte(hexValue(s[i+2:i+4])))
+			i += 4
+			continue
+		}
+		if set.octal && next >= '0' && next <= '7' {
+			end := i + 1
+			for end < len(s) && end < i+4 && s[end] >= '0' && s[end] <= '7' {
+				end++
+			}
+			value := 0
+			for _, d := range s[i+1 : end] {
+				value = value*8 + int(d-'0')
+			}
+			if value <= 0xFF {
+				pending = append(pending, byte(value))
+				i = end
+				continue
+			}
+		}
+		flush()
+
+		if r, size, ok := decodeRuneEscape(s[i:], set); ok {
+			sb.WriteRune(r)
+			i += size
+			continue
+		}
+
+		replacement, ok := simpleEscape(next, set, oneLine)
+		if !ok {
+			// 无法识别的转义序列原样保留
+			sb.WriteString(s[i : i+2])
+		} else {
+			sb.WriteString(replacement)
+		}
+		i += 2
+	}
+	flush()
+	return sb.String()
+}
+
+// simpleEscape 处理单字符转义，返回替换文本
+func simpleEscape(ch byte, set escapeSet, oneLine bool) (string, bool) {
+	if set.basic {
+		switch ch {
+		case 'n':
+			if oneLine {
+				return " ", true
+			}
+			return "\n", true
+		case 'r':
+			if oneLine {
+				return "", true
+			}
+			return "\r", true
+		case 't':
+			return "\t", true
+		case '\\':
+			return "\\", true
+		case '"':
+			return "\"", true
+		case '\'':
+			return "'", true
+		}
+	}
+	if set.json {
+		switch ch {
+		case 'b':
+			return "\b", true
+		case 'f':
+			return "\f", true
+		case '/':
+			return "/", true
+		}
+	}
+	return "", false
+}
+
+// decodeRuneEscape 解码 \uXXXX（含 UTF-16 代理对）和 \UXXXXXXXX
+// 返回解码得到的字符和消费的字节数
+func decodeRuneEscape(s string, set escapeSet) (rune, int, bool) {
+	if !set.unicode || len(s) < 2 {
+		return 0, 0, false
+	}
+	switch s[1] {
+	case 'u':
+		if len(s) < 6 || !isHexDigits(s[2:6]) {
+			return 0, 0, false
+		}
+		r := rune(hexValue(s[2:6]))
+		if utf16.IsSurrogate(r) {
+			// 代理对：必须紧跟另一个 \uXXXX
+			if len(s) >= 12 && s[6] == '\\' && s[7] == 'u' && isHexDigits(s[8:12]) {
+				if combined := utf16.DecodeRune(r, rune(hexValue(s[8:12]))); combined != utf8.RuneError {
+					return combined, 12, true
+				}
+			}
+			return 0, 0, false // 孤立的代理项原样保留
+		}
+		return r, 6, true
+	case 'U':
+		if len(s) < 10 || !isHexDigits(s[2:10]) {
+			return 0, 0, false
+		}
+		r := rune(hexValue(s[2:10]))
+		if !utf8.ValidRune(r) {
+			return 0, 0, false
+		}
+		return r, 10, true
+	}
+	return 0, 0, false
+}
+
+// isHexDigits 检查字符串是否全部由十六进制数字组成
+func isHexDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if hexDigit(s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hexValue 将十六进制数字串转换为整数（调用前需确认是合法的十六进制）
+func hexValue(s string) int {
+	value := 0
+	for i := 0; i < len(s); i++ {
+		value = value*16 + hexDigit(s[i])
+	}
+	return value
+}
+
+// hexDigit 返回单个十六进制数字的值，不合法时返回 -1
+func hexDigit(ch byte) int {
+	switch {
+	case ch >= '0' && ch <= '9':
+		return int(ch - '0')
+	case ch >= 'a' && ch <= 'f':
+		return int(ch-'a') + 10
+	case ch >= 'A' && ch <= 'F':
+		return int(ch-'A') + 10
+	}
+	return -1
+}